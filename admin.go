@@ -0,0 +1,159 @@
+package slog
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+type adminWriterLevel struct {
+	Index int            `json:"index"`
+	Type  string         `json:"type"`
+	Level LogLevelString `json:"level"`
+}
+
+// AdminHandler returns an http.Handler exposing the logger's recent logs and writer levels:
+//
+//	GET /logs?limit=100&format=json&level=warn   recent entries from the LogBuffer
+//	GET /levels                                  level of each writer
+//	PUT /levels?level=debug[&writer=0]           set the level of all writers, or one by index
+//
+// Mount it under a prefix with http.StripPrefix.
+func AdminHandler(l *SLogger) http.Handler {
+	if l == nil {
+		l = Slog
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /logs", func(w http.ResponseWriter, r *http.Request) {
+		adminGetLogs(l, w, r)
+	})
+	mux.HandleFunc("GET /levels", func(w http.ResponseWriter, r *http.Request) {
+		adminGetLevels(l, w, r)
+	})
+	mux.HandleFunc("PUT /levels", func(w http.ResponseWriter, r *http.Request) {
+		adminPutLevels(l, w, r)
+	})
+	return mux
+}
+
+func adminGetLogs(l *SLogger, w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	var limit int64
+	if s := q.Get("limit"); s != "" {
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || n < 0 {
+			http.Error(w, fmt.Sprintf("invalid limit: %q", s), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	format := FormatJson
+	if s := q.Get("format"); s != "" {
+		if !IsValidLogFormat(s) {
+			http.Error(w, fmt.Sprintf("invalid format: %q", s), http.StatusBadRequest)
+			return
+		}
+		format = ToLogFormat(s)
+	}
+
+	var minLevel LogLevel
+	if s := q.Get("level"); s != "" {
+		if !IsValidLogLevel(s) {
+			http.Error(w, fmt.Sprintf("invalid level: %q", s), http.StatusBadRequest)
+			return
+		}
+		minLevel = ToLogLevel(s)
+	}
+
+	var logs []interface{}
+	var count int64
+	if minLevel == 0 {
+		logs, count = l.Buffer.GetLogsInterface(limit, format)
+	} else {
+		// filter the whole buffer first so limit applies to matching entries
+		all := l.Buffer.GetLogs(0)
+		matched := make([]Log, 0, len(all))
+		for _, log := range all {
+			if log.Level.rank() >= minLevel.rank() {
+				matched = append(matched, log)
+			}
+		}
+		if limit > 0 && int64(len(matched)) > limit {
+			matched = matched[len(matched)-int(limit):]
+		}
+
+		logs = make([]interface{}, 0, len(matched))
+		for _, log := range matched {
+			logs = append(logs, EncodeLogToInterface(log, format))
+		}
+		count = int64(len(logs))
+	}
+
+	writeAdminJson(w, http.StatusOK, map[string]interface{}{
+		"logs":  logs,
+		"count": count,
+	})
+}
+
+func adminGetLevels(l *SLogger, w http.ResponseWriter, _ *http.Request) {
+	writeAdminJson(w, http.StatusOK, adminWriterLevels(l))
+}
+
+func adminPutLevels(l *SLogger, w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	s := q.Get("level")
+	if err := checkWriterLevel(s); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	level := ToLogLevel(s)
+
+	idx := q.Get("writer")
+	if idx == "" {
+		l.SetLevel(level)
+		writeAdminJson(w, http.StatusOK, adminWriterLevels(l))
+		return
+	}
+
+	writers := l.Writers()
+	i, err := strconv.Atoi(idx)
+	if err != nil || i < 0 || i >= len(writers) {
+		http.Error(w, fmt.Sprintf("invalid writer: %q", idx), http.StatusBadRequest)
+		return
+	}
+
+	ls, ok := writers[i].(LevelSetter)
+	if !ok {
+		http.Error(w, fmt.Sprintf("writer %d does not support changing its level", i), http.StatusBadRequest)
+		return
+	}
+	ls.SetLevel(level)
+	levelsChanged()
+	writeAdminJson(w, http.StatusOK, adminWriterLevels(l))
+}
+
+func adminWriterLevels(l *SLogger) []adminWriterLevel {
+	writers := l.Writers()
+	levels := make([]adminWriterLevel, 0, len(writers))
+	for i, wr := range writers {
+		levels = append(levels, adminWriterLevel{
+			Index: i,
+			Type:  fmt.Sprintf("%T", wr),
+			Level: ToLogLevelString(wr.Level()),
+		})
+	}
+	return levels
+}
+
+func writeAdminJson(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		fmt.Printf("AdminHandler(): failed to encode response: %v\n", err)
+	}
+}
@@ -0,0 +1,126 @@
+package slog
+
+import (
+	"io"
+	"testing"
+	"time"
+)
+
+// discardWriter encodes every log in its format and throws the bytes away,
+// so the benchmarks measure the logger and encoders without any I/O
+type discardWriter struct {
+	level  LogLevel
+	format LogFormat
+}
+
+func (w *discardWriter) Level() LogLevel { return w.level }
+func (w *discardWriter) Close()          {}
+
+func (w *discardWriter) Write(l *Log) error {
+	b, err := l.Encode(w.format)
+	if err != nil {
+		return err
+	}
+	_, err = io.Discard.Write(b)
+	return err
+}
+
+func newBenchLogger(b *testing.B, formats ...LogFormat) *SLogger {
+	writers := make([]Writer, len(formats))
+	for i, f := range formats {
+		writers[i] = &discardWriter{level: InfoLevel, format: f}
+	}
+	logger, err := NewLogger(writers...)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(logger.Close)
+	b.ReportAllocs()
+	b.ResetTimer()
+	return logger
+}
+
+func BenchmarkDisabledDebug(b *testing.B) {
+	logger := newBenchLogger(b, FormatJson)
+	for i := 0; i < b.N; i++ {
+		logger.Debug("request handled")
+	}
+}
+
+func BenchmarkDisabledDebugArgs(b *testing.B) {
+	logger := newBenchLogger(b, FormatJson)
+	for i := 0; i < b.N; i++ {
+		logger.Debug("request handled", "method", "GET", "status", 200)
+	}
+}
+
+func BenchmarkInfoJson(b *testing.B) {
+	logger := newBenchLogger(b, FormatJson)
+	for i := 0; i < b.N; i++ {
+		logger.Info("request handled", "method", "GET", "status", 200)
+	}
+}
+
+func BenchmarkInfoText(b *testing.B) {
+	logger := newBenchLogger(b, FormatText)
+	for i := 0; i < b.N; i++ {
+		logger.Info("request handled", "method", "GET", "status", 200)
+	}
+}
+
+func BenchmarkInfoAnsi(b *testing.B) {
+	logger := newBenchLogger(b, FormatAnsi)
+	for i := 0; i < b.N; i++ {
+		logger.Info("request handled", "method", "GET", "status", 200)
+	}
+}
+
+// the values are variables, as at most call sites: constants are boxed into
+// interfaces for free, which would hide what loose args cost
+var (
+	benchMethod  = "GET"
+	benchStatus  = 200
+	benchLatency = 1500 * time.Microsecond
+)
+
+func BenchmarkInfoJsonTypedFields(b *testing.B) {
+	logger := newBenchLogger(b, FormatJson)
+	for i := 0; i < b.N; i++ {
+		logger.LogFields(InfoLevel, "request handled",
+			String("method", benchMethod),
+			Int("status", benchStatus),
+			Dur("latency", benchLatency),
+		)
+	}
+}
+
+// typed fields passed as args are boxed like any other arg
+func BenchmarkInfoJsonTypedArgs(b *testing.B) {
+	logger := newBenchLogger(b, FormatJson)
+	for i := 0; i < b.N; i++ {
+		logger.Info("request handled",
+			String("method", benchMethod),
+			Int("status", benchStatus),
+			Dur("latency", benchLatency),
+		)
+	}
+}
+
+func BenchmarkInfoJsonLooseFields(b *testing.B) {
+	logger := newBenchLogger(b, FormatJson)
+	for i := 0; i < b.N; i++ {
+		logger.Info("request handled",
+			"method", benchMethod,
+			"status", benchStatus,
+			"latency", benchLatency,
+		)
+	}
+}
+
+// two json writers share a single encoding of each log
+func BenchmarkInfoMultipleWriters(b *testing.B) {
+	logger := newBenchLogger(b, FormatJson, FormatJson, FormatText)
+	for i := 0; i < b.N; i++ {
+		logger.Info("request handled", "method", "GET", "status", 200)
+	}
+}
@@ -0,0 +1,74 @@
+package slog
+
+import (
+	"path/filepath"
+	"runtime"
+	"strconv"
+)
+
+const (
+	// frames between writeLog and the user's call: writeLog -> info -> Info -> user
+	callerSkip = 3
+
+	maxStackDepth = 32
+)
+
+type Caller struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Function string `json:"function"`
+}
+
+// String returns the short form used in text output, e.g. "main.go:42 main.run"
+func (c *Caller) String() string {
+	return filepath.Base(c.File) + ":" + strconv.Itoa(c.Line) + " " + filepath.Base(c.Function)
+}
+
+// callerPCs returns up to n pcs starting skip frames above the function calling callerPCs
+func callerPCs(skip int, n int) []uintptr {
+	pcs := make([]uintptr, n)
+	// +2 skips runtime.Callers and callerPCs
+	return pcs[:runtime.Callers(skip+2, pcs)]
+}
+
+func toCaller(pc uintptr) *Caller {
+	if pc == 0 {
+		return nil
+	}
+
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	if frame.PC == 0 {
+		return nil
+	}
+
+	return &Caller{
+		File:     frame.File,
+		Line:     frame.Line,
+		Function: frame.Function,
+	}
+}
+
+// toStack resolves pcs into frames, stopping at the runtime's goroutine entry points
+func toStack(pcs []uintptr) []Caller {
+	if len(pcs) == 0 {
+		return nil
+	}
+
+	stack := make([]Caller, 0, len(pcs))
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		if frame.Function == "runtime.main" || frame.Function == "runtime.goexit" {
+			break
+		}
+		stack = append(stack, Caller{
+			File:     frame.File,
+			Line:     frame.Line,
+			Function: frame.Function,
+		})
+		if !more {
+			break
+		}
+	}
+	return stack
+}
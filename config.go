@@ -0,0 +1,510 @@
+package slog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config describes a logger declaratively, e.g. loaded from a JSON file with LoadConfig:
+//
+//	{
+//	  "level": "info",
+//	  "modules": "db=debug,http.client=warn",
+//	  "writers": [
+//	    {"type": "stdout", "format": "ansi"},
+//	    {"type": "file", "file": "logs/app.log", "level": "debug", "rotate_size_kb": 102400},
+//	    {"type": "http", "url": "https://logs.example.com/ingest", "api_key": "..."}
+//	  ]
+//	}
+type Config struct {
+	// Level of writers that do not set their own, "info" if empty
+	Level string `json:"level"`
+	// Modules is a module level spec, see SetModuleLevels. Module levels are
+	// shared by every logger, so if set this replaces any set before.
+	Modules string `json:"modules"`
+
+	ReportCaller    bool   `json:"report_caller"`
+	StackTraceLevel string `json:"stack_trace_level"`
+	// FlushTimeout and DedupWindow are durations such as "3s" or "500ms"
+	FlushTimeout string `json:"flush_timeout"`
+	DedupWindow  string `json:"dedup_window"`
+
+	Sampling *SamplingConfig `json:"sampling"`
+	Redact   *RedactConfig   `json:"redact"`
+
+	// Writers defaults to a single stdout writer if empty
+	Writers []WriterConfig `json:"writers"`
+}
+
+type WriterConfig struct {
+	// Type is "stdout", "stderr", "file" or "http"
+	Type   string `json:"type"`
+	Level  string `json:"level"`
+	Format string `json:"format"`
+	// Stats is "by_level" (default), "always", "never" or "only", see StatMode
+	Stats string `json:"stats"`
+
+	// file writers
+	File         string `json:"file"`
+	RotateSizeKB int64  `json:"rotate_size_kb"`
+
+	// http writers
+	URL    string `json:"url"`
+	Method string `json:"method"`
+	APIKey string `json:"api_key"`
+}
+
+type SamplingConfig struct {
+	Interval   string `json:"interval"`
+	First      int    `json:"first"`
+	Thereafter int    `json:"thereafter"`
+}
+
+type RedactConfig struct {
+	Keys          []string `json:"keys"`
+	KeyPatterns   []string `json:"key_patterns"`
+	ValuePatterns []string `json:"value_patterns"`
+	Mask          string   `json:"mask"`
+}
+
+// ConfigError reports an invalid config value. Field is its path in the
+// document, e.g. "writers[1].level".
+type ConfigError struct {
+	Field string
+	Err   error
+}
+
+func (e *ConfigError) Error() string {
+	return e.Field + ": " + e.Err.Error()
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+func configErrorf(field string, format string, args ...interface{}) error {
+	return &ConfigError{Field: field, Err: fmt.Errorf(format, args...)}
+}
+
+var statModes = map[string]StatMode{
+	"":         StatsByLevel,
+	"by_level": StatsByLevel,
+	"always":   StatsAlways,
+	"never":    StatsNever,
+	"only":     StatsOnly,
+}
+
+// LoadConfig reads and validates a JSON config file. Unknown fields are rejected,
+// so a misspelt option is reported rather than ignored.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, joinError("LoadConfig()", err)
+	}
+
+	cfg, err := ParseConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// ParseConfig decodes and validates a JSON config document.
+func ParseConfig(data []byte) (*Config, error) {
+	var cfg Config
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, jsonConfigError(data, err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after the config object")
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// jsonConfigError adds the position of syntax errors, and the field of type and
+// unknown field errors
+func jsonConfigError(data []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		line, col := lineAndColumn(data, syntaxErr.Offset)
+		return fmt.Errorf("line %d, column %d: %v", line, col, err)
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		// encoding/json writes "writers.0.level", Validate writes "writers[0].level"
+		field := jsonIndexRegex.ReplaceAllString(typeErr.Field, "[$1]")
+		return configErrorf(field, "expected %s, got %s", typeErr.Type, typeErr.Value)
+	}
+
+	// encoding/json reports unknown fields by name only
+	if s, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		if name, uerr := strconv.Unquote(s); uerr == nil {
+			return configErrorf(unknownFieldPath(data, name), "unknown field")
+		}
+	}
+	return err
+}
+
+// unknownFieldPath returns the path of the unknown field name in data, e.g.
+// "writers[0].levle", or name if it can't be found
+func unknownFieldPath(data []byte, name string) string {
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return name
+	}
+	if path, ok := findUnknownField(doc, reflect.TypeOf(Config{}), "", name); ok {
+		return path
+	}
+	return name
+}
+
+// findUnknownField walks v, decoded from json into t, for a key named name that
+// t has no field for
+func findUnknownField(v interface{}, t reflect.Type, path, name string) (string, bool) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if t.Kind() != reflect.Struct {
+			return "", false
+		}
+		for _, k := range sortedKeys(v) {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			ft, ok := jsonFieldType(t, k)
+			if !ok {
+				if k == name {
+					return p, true
+				}
+				continue
+			}
+			if p, ok := findUnknownField(v[k], ft, p, name); ok {
+				return p, true
+			}
+		}
+
+	case []interface{}:
+		if t.Kind() != reflect.Slice {
+			return "", false
+		}
+		for i, e := range v {
+			if p, ok := findUnknownField(e, t.Elem(), fmt.Sprintf("%s[%d]", path, i), name); ok {
+				return p, true
+			}
+		}
+	}
+	return "", false
+}
+
+// jsonFieldType returns the type of the field of struct t that encoding/json
+// decodes key into, matching names case-insensitively like it does
+func jsonFieldType(t reflect.Type, key string) (reflect.Type, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "" {
+			name = sf.Name
+		}
+		if strings.EqualFold(name, key) {
+			return sf.Type, true
+		}
+	}
+	return nil, false
+}
+
+var jsonIndexRegex = regexp.MustCompile(`\.(\d+)`)
+
+func lineAndColumn(data []byte, offset int64) (int, int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	col := int(offset) - bytes.LastIndexByte(before, '\n')
+	return line, col
+}
+
+// Validate checks every field of cfg and returns all problems found, each as a
+// *ConfigError naming the field.
+func (cfg *Config) Validate() error {
+	var errs []error
+
+	checkLevel := func(field, v string) {
+		if v != "" && !IsValidLogLevel(v) {
+			errs = append(errs, configErrorf(field, "invalid level %q", v))
+		}
+	}
+	checkDuration := func(field, v string) {
+		if v == "" {
+			return
+		}
+		if d, err := time.ParseDuration(v); err != nil || d < 0 {
+			errs = append(errs, configErrorf(field, "invalid duration %q", v))
+		}
+	}
+
+	if cfg.Level != "" {
+		if err := checkWriterLevel(cfg.Level); err != nil {
+			errs = append(errs, &ConfigError{Field: "level", Err: err})
+		}
+	}
+	checkLevel("stack_trace_level", cfg.StackTraceLevel)
+	checkDuration("flush_timeout", cfg.FlushTimeout)
+	checkDuration("dedup_window", cfg.DedupWindow)
+
+	if _, err := parseModuleLevels(cfg.Modules); err != nil {
+		errs = append(errs, &ConfigError{Field: "modules", Err: err})
+	}
+
+	if cfg.Sampling != nil {
+		checkDuration("sampling.interval", cfg.Sampling.Interval)
+		if cfg.Sampling.First < 0 {
+			errs = append(errs, configErrorf("sampling.first", "must not be negative"))
+		}
+		if cfg.Sampling.Thereafter < 0 {
+			errs = append(errs, configErrorf("sampling.thereafter", "must not be negative"))
+		}
+	}
+
+	if cfg.Redact != nil {
+		if _, err := newRedactor(cfg.Redact.options()); err != nil {
+			errs = append(errs, &ConfigError{Field: "redact", Err: err})
+		}
+	}
+
+	for i, w := range cfg.Writers {
+		errs = append(errs, w.validate(fmt.Sprintf("writers[%d]", i))...)
+	}
+
+	return errors.Join(errs...)
+}
+
+func (w *WriterConfig) validate(field string) []error {
+	var errs []error
+
+	if w.Level != "" {
+		if err := checkWriterLevel(w.Level); err != nil {
+			errs = append(errs, &ConfigError{Field: field + ".level", Err: err})
+		}
+	}
+	if w.Format != "" && !IsValidLogFormat(w.Format) {
+		errs = append(errs, configErrorf(field+".format", "invalid format %q, expected json, text or ansi", w.Format))
+	}
+	if _, ok := statModes[w.Stats]; !ok {
+		errs = append(errs, configErrorf(field+".stats", "invalid stats mode %q, expected by_level, always, never or only", w.Stats))
+	}
+
+	switch w.Type {
+	case "stdout", "stderr":
+
+	case "file":
+		if w.File == "" {
+			errs = append(errs, configErrorf(field+".file", "required for file writers"))
+		}
+		if w.RotateSizeKB < 0 {
+			errs = append(errs, configErrorf(field+".rotate_size_kb", "must not be negative"))
+		}
+
+	case "http":
+		if w.URL == "" {
+			errs = append(errs, configErrorf(field+".url", "required for http writers"))
+		} else if _, err := url.ParseRequestURI(w.URL); err != nil {
+			errs = append(errs, configErrorf(field+".url", "invalid URL %q", w.URL))
+		}
+		if w.Method != "" && !isValidHttpMethod(strings.ToUpper(w.Method)) {
+			errs = append(errs, configErrorf(field+".method", "invalid HTTP method %q", w.Method))
+		}
+
+	case "":
+		errs = append(errs, configErrorf(field+".type", "required, expected stdout, stderr, file or http"))
+
+	default:
+		errs = append(errs, configErrorf(field+".type", "invalid writer type %q, expected stdout, stderr, file or http", w.Type))
+	}
+
+	return errs
+}
+
+func (r *RedactConfig) options() *RedactOptions {
+	return &RedactOptions{
+		Keys:          r.Keys,
+		KeyPatterns:   r.KeyPatterns,
+		ValuePatterns: r.ValuePatterns,
+		Mask:          r.Mask,
+	}
+}
+
+// NewFromConfig validates cfg and builds a logger from it. No writer is started
+// unless the whole config is valid.
+func NewFromConfig(cfg *Config) (*SLogger, error) {
+	if cfg == nil {
+		return nil, errors.New("config is nil")
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	writers, err := newWriters(cfg.writerConfigs())
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := NewLogger(writers...)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.apply(l); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// writerConfigs returns the writers of a validated config with the default
+// level filled in, or a single stdout writer if it has none
+func (cfg *Config) writerConfigs() []WriterConfig {
+	level := cfg.Level
+	if level == "" {
+		level = string(InfoLevelString)
+	}
+
+	if len(cfg.Writers) == 0 {
+		return []WriterConfig{{Type: "stdout", Level: level}}
+	}
+
+	wcs := make([]WriterConfig, 0, len(cfg.Writers))
+	for _, wc := range cfg.Writers {
+		if wc.Level == "" {
+			wc.Level = level
+		}
+		wcs = append(wcs, wc)
+	}
+	return wcs
+}
+
+// newWriters builds writers from validated writer configs, closing the ones
+// already built if one fails
+func newWriters(wcs []WriterConfig) ([]Writer, error) {
+	writers := make([]Writer, 0, len(wcs))
+	for i, wc := range wcs {
+		w, err := wc.newWriter()
+		if err != nil {
+			for _, w := range writers {
+				w.Close()
+			}
+			return nil, &ConfigError{Field: fmt.Sprintf("writers[%d]", i), Err: err}
+		}
+		writers = append(writers, w)
+	}
+	return writers, nil
+}
+
+func (wc *WriterConfig) newWriter() (Writer, error) {
+	level := ToLogLevel(wc.Level)
+	stats := statModes[wc.Stats]
+
+	switch wc.Type {
+	case "stdout", "stderr":
+		format := FormatAnsi
+		if wc.Format != "" {
+			format = ToLogFormat(wc.Format)
+		}
+		stream := StdOut
+		if wc.Type == "stderr" {
+			stream = StdErr
+		}
+		return WithStdIoWriter(&ToStdStreamWriterOptions{
+			Level:  level,
+			Stats:  stats,
+			Format: format,
+			Stream: stream,
+		}), nil
+
+	case "file":
+		return WithToFileWriter(&ToFileWriterOptions{
+			FileName:   wc.File,
+			Format:     LogFormat(wc.Format),
+			Level:      level,
+			Stats:      stats,
+			RotateSize: wc.RotateSizeKB,
+		}), nil
+
+	case "http":
+		w := WithToHttpWriter(&ToHttpWriterOptions{
+			Level:  level,
+			Stats:  stats,
+			Format: LogFormat(wc.Format),
+			URL:    wc.URL,
+			Method: strings.ToUpper(wc.Method),
+			APIKey: wc.APIKey,
+		})
+		if w == nil {
+			return nil, errors.New("invalid http writer options")
+		}
+		return w, nil
+
+	default:
+		return nil, fmt.Errorf("invalid writer type %q", wc.Type)
+	}
+}
+
+// apply sets the logger-wide options of a validated config on l
+func (cfg *Config) apply(l *SLogger) error {
+	l.SetReportCaller(cfg.ReportCaller)
+	if cfg.StackTraceLevel != "" {
+		l.SetStackTraceLevel(ToLogLevel(cfg.StackTraceLevel))
+	}
+	if cfg.FlushTimeout != "" {
+		d, _ := time.ParseDuration(cfg.FlushTimeout)
+		l.SetFlushTimeout(d)
+	}
+	if cfg.DedupWindow != "" {
+		d, _ := time.ParseDuration(cfg.DedupWindow)
+		l.SetDedup(d)
+	}
+
+	if cfg.Sampling != nil {
+		opt := &SamplingOptions{
+			First:      cfg.Sampling.First,
+			Thereafter: cfg.Sampling.Thereafter,
+		}
+		if cfg.Sampling.Interval != "" {
+			opt.Interval, _ = time.ParseDuration(cfg.Sampling.Interval)
+		}
+		l.SetSampling(opt)
+	}
+
+	if cfg.Redact != nil {
+		if err := l.SetRedaction(cfg.Redact.options()); err != nil {
+			return &ConfigError{Field: "redact", Err: err}
+		}
+	}
+
+	if cfg.Modules != "" {
+		if err := SetModuleLevels(cfg.Modules); err != nil {
+			return &ConfigError{Field: "modules", Err: err}
+		}
+	}
+	return nil
+}
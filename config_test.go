@@ -0,0 +1,161 @@
+package slog
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   Config
+		field string
+	}{
+		{"level", Config{Level: "loud"}, "level"},
+		{"stack trace level", Config{StackTraceLevel: "loud"}, "stack_trace_level"},
+		{"flush timeout", Config{FlushTimeout: "soon"}, "flush_timeout"},
+		{"negative dedup window", Config{DedupWindow: "-1s"}, "dedup_window"},
+		{"modules", Config{Modules: "db=loud"}, "modules"},
+		{"sampling interval", Config{Sampling: &SamplingConfig{Interval: "x"}}, "sampling.interval"},
+		{"sampling first", Config{Sampling: &SamplingConfig{First: -1}}, "sampling.first"},
+		{"sampling thereafter", Config{Sampling: &SamplingConfig{Thereafter: -1}}, "sampling.thereafter"},
+		{"redact key pattern", Config{Redact: &RedactConfig{KeyPatterns: []string{"["}}}, "redact"},
+		{"redact value pattern", Config{Redact: &RedactConfig{ValuePatterns: []string{"("}}}, "redact"},
+		{"writer level", Config{Writers: []WriterConfig{{Type: "stdout", Level: "loud"}}}, "writers[0].level"},
+		{"writer format", Config{Writers: []WriterConfig{{Type: "stdout", Format: "xml"}}}, "writers[0].format"},
+		{"writer stats", Config{Writers: []WriterConfig{{Type: "stdout", Stats: "sometimes"}}}, "writers[0].stats"},
+		{"writer type missing", Config{Writers: []WriterConfig{{}}}, "writers[0].type"},
+		{"writer type", Config{Writers: []WriterConfig{{Type: "syslog"}}}, "writers[0].type"},
+		{"file missing", Config{Writers: []WriterConfig{{Type: "file"}}}, "writers[0].file"},
+		{"rotate size", Config{Writers: []WriterConfig{{Type: "file", File: "x.log", RotateSizeKB: -1}}}, "writers[0].rotate_size_kb"},
+		{"url missing", Config{Writers: []WriterConfig{{Type: "http"}}}, "writers[0].url"},
+		{"url", Config{Writers: []WriterConfig{{Type: "http", URL: "not a url"}}}, "writers[0].url"},
+		{"method", Config{Writers: []WriterConfig{{Type: "http", URL: "http://x", Method: "FETCH"}}}, "writers[0].method"},
+		{"second writer", Config{Writers: []WriterConfig{{Type: "stdout"}, {Type: "stderr", Level: "loud"}}}, "writers[1].level"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); !hasConfigError(err, tt.field) {
+				t.Errorf("Validate() = %v, want an error for %s", err, tt.field)
+			}
+		})
+	}
+
+	valid := Config{
+		Level:        "debug",
+		FlushTimeout: "3s",
+		Sampling:     &SamplingConfig{Interval: "1s", First: 10},
+		Writers: []WriterConfig{
+			{Type: "stdout", Format: "json", Stats: "never"},
+			{Type: "http", URL: "https://logs.example.com", Method: "put"},
+		},
+	}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+}
+
+func TestValidateReportsEveryError(t *testing.T) {
+	cfg := Config{Level: "loud", Writers: []WriterConfig{{Type: "file", Format: "xml"}}}
+	err := cfg.Validate()
+	for _, field := range []string{"level", "writers[0].format", "writers[0].file"} {
+		if !hasConfigError(err, field) {
+			t.Errorf("Validate() = %v, want an error for %s", err, field)
+		}
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string
+		field string // the ConfigError field, or "" for a plain error
+		want  string // in the message
+	}{
+		{"syntax", "{\n  \"level\": \"info\",,\n}", "", "line 2"},
+		{"type", `{"writers": [{"type": "stdout", "level": 1}]}`, "writers[0].level", "expected string"},
+		{"unknown field", `{"levle": "info"}`, "levle", "unknown field"},
+		{"unknown writer field", `{"writers": [{"type": "stdout"}, {"type": "stdout", "levle": "info"}]}`, "writers[1].levle", "unknown field"},
+		{"unknown nested field", `{"sampling": {"first": 1, "every": 2}}`, "sampling.every", "unknown field"},
+		{"trailing data", `{"level": "info"} {}`, "", "unexpected data"},
+		{"invalid", `{"writers": [{"type": "file"}]}`, "writers[0].file", "required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := ParseConfig([]byte(tt.data))
+			if err == nil {
+				t.Fatalf("ParseConfig() = %+v, want an error", cfg)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseConfig() = %v, want %q in the error", err, tt.want)
+			}
+			var ce *ConfigError
+			if tt.field == "" && errors.As(err, &ce) {
+				t.Errorf("ParseConfig() = %v, want a plain error", err)
+			}
+			if tt.field != "" && !hasConfigError(err, tt.field) {
+				t.Errorf("ParseConfig() = %v, want an error for %s", err, tt.field)
+			}
+		})
+	}
+
+	// keys match case-insensitively, as encoding/json decodes them
+	cfg, err := ParseConfig([]byte(`{"Level": "warn", "writers": [{"Type": "stderr"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Level != "warn" || cfg.Writers[0].Type != "stderr" {
+		t.Errorf("ParseConfig() = %+v, want level warn and a stderr writer", cfg)
+	}
+}
+
+func TestNewFromConfigErrors(t *testing.T) {
+	if _, err := NewFromConfig(nil); err == nil {
+		t.Error("NewFromConfig(nil) succeeded, want an error")
+	}
+
+	cfg := &Config{Writers: []WriterConfig{{Type: "stdout"}, {Type: "http"}}}
+	if l, err := NewFromConfig(cfg); !hasConfigError(err, "writers[1].url") {
+		t.Errorf("NewFromConfig() = %v, %v, want an error for writers[1].url", l, err)
+	}
+}
+
+func TestNewFromConfigDefaults(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    Config
+		levels []LogLevel
+	}{
+		{"default writer", Config{}, []LogLevel{InfoLevel}},
+		{"default writer at level", Config{Level: "warn"}, []LogLevel{WarnLevel}},
+		{"default level", Config{Writers: []WriterConfig{{Type: "stdout"}, {Type: "stderr", Level: "error"}}}, []LogLevel{InfoLevel, ErrorLevel}},
+		{"config level", Config{Level: "debug", Writers: []WriterConfig{{Type: "stdout"}, {Type: "stderr", Level: "error"}}}, []LogLevel{DebugLevel, ErrorLevel}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := NewFromConfig(&tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer l.Close()
+
+			writers := l.Writers()
+			if len(writers) != len(tt.levels) {
+				t.Fatalf("got %d writers, want %d", len(writers), len(tt.levels))
+			}
+			for i, w := range writers {
+				if w.Level() != tt.levels[i] {
+					t.Errorf("writers[%d] level = %v, want %v", i, w.Level(), tt.levels[i])
+				}
+			}
+		})
+	}
+
+	if wcs := (&Config{}).writerConfigs(); len(wcs) != 1 || wcs[0].Type != "stdout" {
+		t.Errorf("writerConfigs() = %+v, want a single stdout writer", wcs)
+	}
+}
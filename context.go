@@ -0,0 +1,204 @@
+package slog
+
+import "context"
+
+// ContextExtractor returns key/value pairs pulled from ctx, e.g. a trace or tenant id.
+type ContextExtractor func(ctx context.Context) []interface{}
+
+type ctxLoggerKey struct{}
+
+// NewContext returns a copy of ctx that carries l.
+func NewContext(ctx context.Context, l *SLogger) context.Context {
+	return context.WithValue(ctx, ctxLoggerKey{}, l)
+}
+
+// FromContext returns the logger stored in ctx by NewContext, or the default logger.
+func FromContext(ctx context.Context) *SLogger {
+	if ctx != nil {
+		if l, ok := ctx.Value(ctxLoggerKey{}).(*SLogger); ok && l != nil {
+			return l
+		}
+	}
+	return Slog
+}
+
+// AddContextExtractor registers e to add fields from the ctx of every Ctx log.
+// It is safe to call while the logger is in use; logs already being written may
+// not see e. Children created with With before the call do not get e.
+func (s *SLogger) AddContextExtractor(e ContextExtractor) {
+	if e == nil {
+		return
+	}
+
+	// copy-on-write, so withContext can range over the slice without a lock
+	for {
+		old := s.extractors.Load()
+		var extractors []ContextExtractor
+		if old != nil {
+			extractors = make([]ContextExtractor, 0, len(*old)+1)
+			extractors = append(extractors, *old...)
+		}
+		extractors = append(extractors, e)
+		if s.extractors.CompareAndSwap(old, &extractors) {
+			return
+		}
+	}
+}
+
+func AddContextExtractor(e ContextExtractor) {
+	if Slog == nil {
+		return
+	}
+	Slog.AddContextExtractor(e)
+}
+
+func (s *SLogger) withContext(ctx context.Context, args []interface{}) []interface{} {
+	extractors := s.extractors.Load()
+	if ctx == nil || extractors == nil {
+		return args
+	}
+
+	var ctxArgs []interface{}
+	for _, e := range *extractors {
+		ctxArgs = append(ctxArgs, e(ctx)...)
+	}
+	if len(ctxArgs) == 0 {
+		return args
+	}
+
+	return append(ctxArgs, args...)
+}
+
+func (s *SLogger) debugCtx(ctx context.Context, msg string, args ...interface{}) {
+	if !s.Enabled(DebugLevel) {
+		return
+	}
+	s.writeLog(DebugLevel, msg, s.withContext(ctx, args)...)
+}
+
+func (s *SLogger) infoCtx(ctx context.Context, msg string, args ...interface{}) {
+	if !s.Enabled(InfoLevel) {
+		return
+	}
+	s.writeLog(InfoLevel, msg, s.withContext(ctx, args)...)
+}
+
+func (s *SLogger) warnCtx(ctx context.Context, msg string, args ...interface{}) {
+	if !s.Enabled(WarnLevel) {
+		return
+	}
+	s.writeLog(WarnLevel, msg, s.withContext(ctx, args)...)
+}
+
+func (s *SLogger) errorCtx(ctx context.Context, msg string, args ...interface{}) {
+	if !s.Enabled(ErrorLevel) {
+		return
+	}
+	s.writeLog(ErrorLevel, msg, s.withContext(ctx, args)...)
+}
+
+func (s *SLogger) fatalCtx(ctx context.Context, msg string, args ...interface{}) {
+	s.writeLog(FatalLevel, msg, s.withContext(ctx, args)...)
+	s.exit()
+}
+
+func (s *SLogger) statCtx(ctx context.Context, msg string, args ...interface{}) {
+	if !s.Enabled(StatLevel) {
+		return
+	}
+	s.writeLog(StatLevel, msg, s.withContext(ctx, args)...)
+}
+
+func (s *SLogger) panicCtx(ctx context.Context, msg string, args ...interface{}) {
+	s.writeLog(PanicLevel, msg, s.withContext(ctx, args)...)
+	s.flushAndPanic(msg)
+}
+
+func (s *SLogger) logAtCtx(ctx context.Context, lvl LogLevel, msg string, args ...interface{}) {
+	if !s.Enabled(lvl) {
+		return
+	}
+	s.writeLog(lvl, msg, s.withContext(ctx, args)...)
+}
+
+func (s *SLogger) DebugCtx(ctx context.Context, msg string, args ...interface{}) {
+	s.debugCtx(ctx, msg, args...)
+}
+
+func (s *SLogger) InfoCtx(ctx context.Context, msg string, args ...interface{}) {
+	s.infoCtx(ctx, msg, args...)
+}
+
+func (s *SLogger) WarnCtx(ctx context.Context, msg string, args ...interface{}) {
+	s.warnCtx(ctx, msg, args...)
+}
+
+func (s *SLogger) ErrorCtx(ctx context.Context, msg string, args ...interface{}) {
+	s.errorCtx(ctx, msg, args...)
+}
+
+func (s *SLogger) FatalCtx(ctx context.Context, msg string, args ...interface{}) {
+	s.fatalCtx(ctx, msg, args...)
+}
+
+func (s *SLogger) StatCtx(ctx context.Context, msg string, args ...interface{}) {
+	s.statCtx(ctx, msg, args...)
+}
+
+func (s *SLogger) PanicCtx(ctx context.Context, msg string, args ...interface{}) {
+	s.panicCtx(ctx, msg, args...)
+}
+
+func (s *SLogger) LogAtCtx(ctx context.Context, lvl LogLevel, msg string, args ...interface{}) {
+	s.logAtCtx(ctx, lvl, msg, args...)
+}
+
+// the package level Ctx functions log through the logger stored in ctx, if any
+
+func DebugCtx(ctx context.Context, msg string, args ...interface{}) {
+	if l := FromContext(ctx); l != nil {
+		l.debugCtx(ctx, msg, args...)
+	}
+}
+
+func InfoCtx(ctx context.Context, msg string, args ...interface{}) {
+	if l := FromContext(ctx); l != nil {
+		l.infoCtx(ctx, msg, args...)
+	}
+}
+
+func WarnCtx(ctx context.Context, msg string, args ...interface{}) {
+	if l := FromContext(ctx); l != nil {
+		l.warnCtx(ctx, msg, args...)
+	}
+}
+
+func ErrorCtx(ctx context.Context, msg string, args ...interface{}) {
+	if l := FromContext(ctx); l != nil {
+		l.errorCtx(ctx, msg, args...)
+	}
+}
+
+func FatalCtx(ctx context.Context, msg string, args ...interface{}) {
+	if l := FromContext(ctx); l != nil {
+		l.fatalCtx(ctx, msg, args...)
+	}
+}
+
+func StatCtx(ctx context.Context, msg string, args ...interface{}) {
+	if l := FromContext(ctx); l != nil {
+		l.statCtx(ctx, msg, args...)
+	}
+}
+
+func PanicCtx(ctx context.Context, msg string, args ...interface{}) {
+	if l := FromContext(ctx); l != nil {
+		l.panicCtx(ctx, msg, args...)
+	}
+}
+
+func LogAtCtx(ctx context.Context, lvl LogLevel, msg string, args ...interface{}) {
+	if l := FromContext(ctx); l != nil {
+		l.logAtCtx(ctx, lvl, msg, args...)
+	}
+}
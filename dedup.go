@@ -0,0 +1,211 @@
+package slog
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// deduper collapses consecutive identical logs (same level, logger, message and args)
+// within a window into the first one, followed by a summary log once the run ends
+type deduper struct {
+	window time.Duration
+	emit   func(*Log)
+
+	mu       sync.Mutex
+	sig      string
+	lvl      LogLevel
+	logger   string
+	typ      string
+	color    string
+	opened   bool
+	first    time.Time
+	repeated int
+	timer    *time.Timer
+}
+
+func newDeduper(window time.Duration, emit func(*Log)) *deduper {
+	return &deduper{
+		window: window,
+		emit:   emit,
+	}
+}
+
+// logSignature identifies a log by level, logger, message and args
+func logSignature(l *Log) string {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	buf.Write(strconv.AppendInt(buf.AvailableBuffer(), int64(l.Level), 10))
+	buf.WriteByte(0)
+	buf.WriteString(l.Logger)
+	buf.WriteByte(0)
+	buf.WriteString(l.Msg)
+	buf.WriteByte(0)
+	writeJsonFields(buf, l.orderedFields())
+	return buf.String()
+}
+
+// admit reports whether l should be written. A repeat of the previous log within
+// the window is counted instead; the summary of a finished run is emitted first.
+func (d *deduper) admit(l *Log) bool {
+	sig := logSignature(l)
+	ts := l.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+
+	d.mu.Lock()
+	if sig == d.sig && ts.Sub(d.first) < d.window {
+		d.repeated++
+		if d.timer == nil {
+			d.timer = time.AfterFunc(d.window-ts.Sub(d.first), d.flush)
+		}
+		d.mu.Unlock()
+		return false
+	}
+
+	summary := d.takeSummary()
+	d.sig = sig
+	d.lvl = l.Level
+	d.logger = l.Logger
+	d.typ = l.Type
+	d.color = l.TypeColor
+	d.opened = l.moduleOpened
+	d.first = ts
+	d.mu.Unlock()
+
+	if summary != nil {
+		d.emit(summary)
+		putLog(summary)
+	}
+	return true
+}
+
+// flush emits the summary of the current run, if any
+func (d *deduper) flush() {
+	d.mu.Lock()
+	summary := d.takeSummary()
+	d.mu.Unlock()
+
+	if summary != nil {
+		d.emit(summary)
+		putLog(summary)
+	}
+}
+
+// takeSummary must be called with d.mu held. It ends the current run and
+// returns its "last message repeated N times" log, or nil if nothing repeated.
+func (d *deduper) takeSummary() *Log {
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	if d.repeated == 0 {
+		return nil
+	}
+
+	l := getLog()
+	l.Level = d.lvl
+	l.Logger = d.logger
+	l.Type = d.typ
+	l.TypeColor = d.color
+	l.moduleOpened = d.opened
+	l.Timestamp = time.Now()
+	l.Msg = "last message repeated " + strconv.Itoa(d.repeated) + " times"
+
+	d.repeated = 0
+	// a repeat after the summary starts a new run
+	d.sig = ""
+	return l
+}
+
+// SetDedup collapses consecutive identical logs (same level, message and args)
+// within window into one, followed by a "last message repeated N times" log.
+// A window of 0 disables it, after writing the summary of a pending run.
+// Use WithDedupWriter to deduplicate for a single writer instead.
+func (s *SLogger) SetDedup(window time.Duration) {
+	var d *deduper
+	if window > 0 {
+		d = newDeduper(window, s.writeEntry)
+	}
+	if old := s.deduper.Swap(d); old != nil {
+		old.flush()
+	}
+}
+
+func SetDedup(window time.Duration) {
+	if Slog == nil {
+		return
+	}
+	Slog.SetDedup(window)
+}
+
+type dedupWriter struct {
+	w Writer
+	d *deduper
+}
+
+// WithDedupWriter wraps w so that consecutive identical logs within window are
+// written to it once, followed by a "last message repeated N times" log.
+func WithDedupWriter(w Writer, window time.Duration) *dedupWriter {
+	if w == nil {
+		// will be skipped
+		return nil
+	}
+
+	dw := &dedupWriter{w: w}
+	dw.d = newDeduper(window, func(l *Log) {
+		if err := w.Write(l); err != nil {
+			fmt.Printf("dedupWriter.emit(): failed to write log entry: %v\n", err)
+		}
+	})
+	return dw
+}
+
+func (w *dedupWriter) Level() LogLevel {
+	return w.w.Level()
+}
+
+func (w *dedupWriter) SetLevel(l LogLevel) {
+	if ls, ok := w.w.(LevelSetter); ok {
+		ls.SetLevel(l)
+	}
+}
+
+func (w *dedupWriter) StatMode() StatMode {
+	return statModeOf(w.w)
+}
+
+func (w *dedupWriter) Write(l *Log) error {
+	if l == nil {
+		return nil
+	}
+	if !w.d.admit(l) {
+		return nil
+	}
+	return w.w.Write(l)
+}
+
+// Flush writes the summary of a pending run, then flushes the wrapped writer
+func (w *dedupWriter) Flush(ctx context.Context) error {
+	w.d.flush()
+	if f, ok := w.w.(Flusher); ok {
+		return f.Flush(ctx)
+	}
+	return nil
+}
+
+func (w *dedupWriter) Close() {
+	w.d.flush()
+	w.w.Close()
+}
+
+var (
+	_ Writer      = &dedupWriter{}
+	_ LevelSetter = &dedupWriter{}
+	_ StatFilter  = &dedupWriter{}
+	_ Flusher     = &dedupWriter{}
+)
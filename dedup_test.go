@@ -0,0 +1,95 @@
+package slog
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func msgs(logs []Log) []string {
+	out := make([]string, len(logs))
+	for i, l := range logs {
+		out[i] = l.Msg
+	}
+	return out
+}
+
+func TestDedupRepeats(t *testing.T) {
+	w := &captureWriter{}
+	l, err := NewLogger(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.SetDedup(time.Hour)
+
+	for i := 0; i < 3; i++ {
+		l.Warn("retrying", "attempt", 1)
+	}
+	if !slices.Equal(msgs(w.logs), []string{"retrying"}) {
+		t.Fatalf("wrote %q, want only the first of the repeats", msgs(w.logs))
+	}
+
+	// different args end the run, its summary comes before the new log
+	l.Warn("retrying", "attempt", 2)
+	if !slices.Equal(msgs(w.logs), []string{"retrying", "last message repeated 2 times", "retrying"}) {
+		t.Fatalf("wrote %q, want the summary before the next log", msgs(w.logs))
+	}
+	if w.logs[1].Level != WarnLevel {
+		t.Errorf("summary level = %v, want the level of the repeated log", w.logs[1].Level)
+	}
+
+	// a single log has no summary, and disabling dedup flushes a pending one
+	l.Info("done")
+	l.Info("done")
+	l.SetDedup(0)
+	l.Info("done")
+	if !slices.Equal(msgs(w.logs[3:]), []string{"done", "last message repeated 1 times", "done"}) {
+		t.Errorf("wrote %q, want the summary when dedup is disabled", msgs(w.logs[3:]))
+	}
+}
+
+func TestDedupSummaryAfterWindow(t *testing.T) {
+	ch := make(chan *Log, 4)
+	l, err := NewLogger(WithToChanWriter(&ToChanWriterOptions{Level: InfoLevel, Ch: ch}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.SetDedup(50 * time.Millisecond)
+
+	for i := 0; i < 4; i++ {
+		l.Info("polling")
+	}
+	if log := <-ch; log.Msg != "polling" {
+		t.Fatalf("Msg = %q, want polling", log.Msg)
+	}
+
+	// no further log arrives, so the window timer writes the summary
+	select {
+	case log := <-ch:
+		if log.Msg != "last message repeated 3 times" {
+			t.Errorf("Msg = %q, want the summary", log.Msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no summary written after the window")
+	}
+}
+
+func TestDedupWriterFlushesOnClose(t *testing.T) {
+	w := &captureWriter{}
+	l, err := NewLogger(WithDedupWriter(w, time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		l.Error("disk full")
+	}
+	if !slices.Equal(msgs(w.logs), []string{"disk full"}) {
+		t.Fatalf("wrote %q, want only the first of the repeats", msgs(w.logs))
+	}
+
+	l.Close()
+	if !slices.Equal(msgs(w.logs), []string{"disk full", "last message repeated 2 times"}) {
+		t.Errorf("wrote %q, want the summary on Close", msgs(w.logs))
+	}
+}
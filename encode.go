@@ -0,0 +1,391 @@
+package slog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+)
+
+const timeFormat = "2006-01-02 15:04:05.000"
+
+// buffers larger than this are dropped instead of returned to the pool
+const maxPooledBufferSize = 64 << 10
+
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+func getBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+func putBuffer(b *bytes.Buffer) {
+	if b == nil || b.Cap() > maxPooledBufferSize {
+		return
+	}
+	b.Reset()
+	bufferPool.Put(b)
+}
+
+const (
+	encJson = iota
+	encText
+	encAnsi
+	numEncodings
+)
+
+func encodingIndex(f LogFormat) int {
+	switch f {
+	case FormatJson:
+		return encJson
+	case FormatAnsi:
+		return encAnsi
+	default:
+		return encText
+	}
+}
+
+// Encode returns l encoded in format f. Each format is encoded at most once per
+// Log and shared by every writer; the returned bytes are only valid until the
+// writer's Write returns.
+func (l *Log) Encode(f LogFormat) ([]byte, error) {
+	i := encodingIndex(f)
+	if l.enc[i] == nil {
+		buf := getBuffer()
+		l.encodeTo(buf, i)
+		l.enc[i] = buf
+	}
+	return l.enc[i].Bytes(), nil
+}
+
+// releaseEncodings returns the cached encodings to the pool
+func (l *Log) releaseEncodings() {
+	for i, buf := range l.enc {
+		putBuffer(buf)
+		l.enc[i] = nil
+	}
+}
+
+func (l *Log) encodeTo(buf *bytes.Buffer, i int) {
+	switch i {
+	case encJson:
+		writeJsonLog(buf, l)
+		buf.WriteByte('\n')
+	case encAnsi:
+		writeTextLog(buf, l, true)
+	default:
+		writeTextLog(buf, l, false)
+	}
+}
+
+// encodeString encodes l without touching its cache, for logs held outside the write path
+func (l *Log) encodeString(f LogFormat) string {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	l.encodeTo(buf, encodingIndex(f))
+	return buf.String()
+}
+
+// MarshalJSON writes the args in call order, and error args as ErrorInfo objects
+// since json.Marshal renders most error types as {}
+func (l Log) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	writeJsonLog(&buf, &l)
+	return buf.Bytes(), nil
+}
+
+func writeJsonLog(buf *bytes.Buffer, l *Log) {
+	buf.WriteString(`{"level":`)
+	writeJsonString(buf, l.Type)
+	if l.Logger != "" {
+		buf.WriteString(`,"logger":`)
+		writeJsonString(buf, l.Logger)
+	}
+	// a zero time, e.g. from a log/slog Record without one, is left out
+	if !l.Timestamp.IsZero() {
+		buf.WriteString(`,"time":"`)
+		buf.Write(l.Timestamp.AppendFormat(buf.AvailableBuffer(), timeFormat))
+		buf.WriteString(`","timestamp":"`)
+		buf.Write(l.Timestamp.AppendFormat(buf.AvailableBuffer(), time.RFC3339Nano))
+		buf.WriteByte('"')
+	}
+	buf.WriteString(`,"msg":`)
+	writeJsonString(buf, l.Msg)
+
+	buf.WriteString(`,"args":`)
+	writeJsonFields(buf, l.orderedFields())
+
+	if l.Caller != nil {
+		buf.WriteString(`,"caller":`)
+		writeJsonCaller(buf, l.Caller)
+	}
+	if len(l.Stack) > 0 {
+		buf.WriteString(`,"stack":[`)
+		for i := range l.Stack {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeJsonCaller(buf, &l.Stack[i])
+		}
+		buf.WriteByte(']')
+	}
+
+	buf.WriteByte('}')
+}
+
+func writeJsonCaller(buf *bytes.Buffer, c *Caller) {
+	buf.WriteString(`{"file":`)
+	writeJsonString(buf, c.File)
+	buf.WriteString(`,"line":`)
+	buf.Write(strconv.AppendInt(buf.AvailableBuffer(), int64(c.Line), 10))
+	buf.WriteString(`,"function":`)
+	writeJsonString(buf, c.Function)
+	buf.WriteByte('}')
+}
+
+func writeJsonFields(buf *bytes.Buffer, fields []Field) {
+	buf.WriteByte('{')
+	for i, f := range fields {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		writeJsonString(buf, f.Key)
+		buf.WriteByte(':')
+		writeJsonField(buf, f)
+	}
+	buf.WriteByte('}')
+}
+
+// writeJsonField writes the value of f, without reflection for typed fields
+func writeJsonField(buf *bytes.Buffer, f Field) {
+	switch f.kind {
+	case kindString:
+		writeJsonString(buf, f.str)
+	case kindInt, kindInt64:
+		buf.Write(strconv.AppendInt(buf.AvailableBuffer(), f.num, 10))
+	case kindFloat64:
+		v := math.Float64frombits(uint64(f.num))
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			// not representable in json
+			writeJsonString(buf, strconv.FormatFloat(v, 'g', -1, 64))
+			return
+		}
+		buf.Write(strconv.AppendFloat(buf.AvailableBuffer(), v, 'g', -1, 64))
+	case kindBool:
+		buf.Write(strconv.AppendBool(buf.AvailableBuffer(), f.num == 1))
+	case kindDuration:
+		writeJsonString(buf, time.Duration(f.num).String())
+	case kindGroup:
+		writeJsonFields(buf, f.val.([]Field))
+	default:
+		writeJsonValue(buf, f.val)
+	}
+}
+
+// writeJsonValue writes v as json. Values json can't encode are written as
+// their %v string instead of failing the whole log.
+func writeJsonValue(buf *bytes.Buffer, v interface{}) {
+	switch v := v.(type) {
+	case nil:
+		buf.WriteString("null")
+		return
+	case string:
+		writeJsonString(buf, v)
+		return
+	case int:
+		buf.Write(strconv.AppendInt(buf.AvailableBuffer(), int64(v), 10))
+		return
+	case int64:
+		buf.Write(strconv.AppendInt(buf.AvailableBuffer(), v, 10))
+		return
+	case bool:
+		buf.Write(strconv.AppendBool(buf.AvailableBuffer(), v))
+		return
+	case error:
+		if isNilError(v) {
+			buf.WriteString("null")
+		} else {
+			writeJsonValue(buf, NewErrorInfo(v))
+		}
+		return
+	}
+
+	b, err := json.Marshal(redactTagged(v))
+	if err != nil {
+		writeJsonString(buf, fmt.Sprintf("%v", v))
+		return
+	}
+	buf.Write(b)
+}
+
+const hexDigits = "0123456789abcdef"
+
+// writeJsonString writes s as a quoted json string
+func writeJsonString(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' {
+				i++
+				continue
+			}
+			buf.WriteString(s[start:i])
+			switch c {
+			case '"', '\\':
+				buf.WriteByte('\\')
+				buf.WriteByte(c)
+			case '\n':
+				buf.WriteString(`\n`)
+			case '\r':
+				buf.WriteString(`\r`)
+			case '\t':
+				buf.WriteString(`\t`)
+			default:
+				buf.WriteString(`\u00`)
+				buf.WriteByte(hexDigits[c>>4])
+				buf.WriteByte(hexDigits[c&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf.WriteString(s[start:i])
+			buf.WriteString(`�`)
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+
+	buf.WriteString(s[start:])
+	buf.WriteByte('"')
+}
+
+// writeTextLog writes the text line of l, with ANSI colors if color is set:
+// [INFO] [2006-01-02 15:04:05.000] [db] [file.go:42 main.run] msg<padding> key=value
+func writeTextLog(buf *bytes.Buffer, l *Log, color bool) {
+	if color {
+		buf.WriteString(FontBold)
+		buf.WriteString(l.TypeColor)
+	}
+	buf.WriteByte('[')
+	buf.WriteString(l.Type)
+	buf.WriteByte(']')
+	if color {
+		buf.WriteString(FontNormal)
+		buf.WriteString(ColorWhite)
+	}
+
+	if !l.Timestamp.IsZero() {
+		buf.WriteString(" [")
+		buf.Write(l.Timestamp.AppendFormat(buf.AvailableBuffer(), timeFormat))
+		buf.WriteByte(']')
+	}
+	if l.Logger != "" {
+		buf.WriteString(" [")
+		buf.WriteString(l.Logger)
+		buf.WriteByte(']')
+	}
+	if l.Caller != nil {
+		buf.WriteString(" [")
+		buf.WriteString(l.Caller.String())
+		buf.WriteByte(']')
+	}
+
+	writePadded(buf, l.Msg)
+	if color {
+		buf.WriteString(ColorWhite)
+	}
+
+	for _, f := range l.orderedFields() {
+		writeTextField(buf, "", f)
+	}
+	buf.WriteByte('\n')
+
+	for _, f := range l.Stack {
+		buf.WriteString("    ")
+		buf.WriteString(f.Function)
+		buf.WriteString("()\n        ")
+		buf.WriteString(f.File)
+		buf.WriteByte(':')
+		buf.Write(strconv.AppendInt(buf.AvailableBuffer(), int64(f.Line), 10))
+		buf.WriteByte('\n')
+	}
+}
+
+// writePadded writes " "+s padded to PadWidth columns, like pad
+func writePadded(buf *bytes.Buffer, s string) {
+	width := runewidth.StringWidth(s) + 1
+	if width > PadWidth {
+		if Truncate {
+			buf.WriteString(runewidth.Truncate(" "+s, PadWidth, ""))
+			return
+		}
+		buf.WriteByte(' ')
+		buf.WriteString(s)
+		return
+	}
+
+	buf.WriteByte(' ')
+	buf.WriteString(s)
+	for ; width < PadWidth; width++ {
+		buf.WriteByte(' ')
+	}
+}
+
+// writeTextField writes f as " key=value", flattening groups into dotted keys
+func writeTextField(buf *bytes.Buffer, prefix string, f Field) {
+	if f.kind == kindGroup {
+		for _, gf := range f.val.([]Field) {
+			writeTextField(buf, prefix+f.Key+".", gf)
+		}
+		return
+	}
+
+	if err, ok := f.val.(error); ok && !isNilError(err) {
+		writeErrorArg(buf, prefix+f.Key, err)
+		return
+	}
+
+	buf.WriteByte(' ')
+	buf.WriteString(prefix)
+	buf.WriteString(f.Key)
+	buf.WriteByte('=')
+
+	switch f.kind {
+	case kindString:
+		buf.WriteString(f.str)
+	case kindInt, kindInt64:
+		buf.Write(strconv.AppendInt(buf.AvailableBuffer(), f.num, 10))
+	case kindFloat64:
+		buf.Write(strconv.AppendFloat(buf.AvailableBuffer(), math.Float64frombits(uint64(f.num)), 'g', -1, 64))
+	case kindBool:
+		buf.Write(strconv.AppendBool(buf.AvailableBuffer(), f.num == 1))
+	case kindDuration:
+		buf.WriteString(time.Duration(f.num).String())
+	default:
+		switch v := f.val.(type) {
+		case string:
+			buf.WriteString(v)
+		case int:
+			buf.Write(strconv.AppendInt(buf.AvailableBuffer(), int64(v), 10))
+		default:
+			fmt.Fprintf(buf, "%v", redactTagged(v))
+		}
+	}
+}
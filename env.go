@@ -0,0 +1,98 @@
+package slog
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Environment variables read by NewLoggerFromEnv and the default logger
+const (
+	EnvLevel   = "SLOG_LEVEL"    // level of every writer, e.g. "debug"
+	EnvFormat  = "SLOG_FORMAT"   // format of the stream writer: "ansi" (default), "text" or "json"
+	EnvStream  = "SLOG_STREAM"   // "stdout" (default), "stderr" or "none"
+	EnvFile    = "SLOG_FILE"     // adds a JSON file writer with this file name
+	EnvHttpURL = "SLOG_HTTP_URL" // adds a JSON HTTP writer sending to this URL
+)
+
+// NewLoggerFromEnv builds a logger from the SLOG_* environment variables.
+// Without any of them set it is the same as NewLogger(). The default logger is
+// built this way at init, before custom levels can be registered; to use a
+// custom level in SLOG_LEVEL, register it and then call
+// SetLogger(NewLoggerFromEnv()).
+func NewLoggerFromEnv() (*SLogger, error) {
+	level := InfoLevel
+	if v := os.Getenv(EnvLevel); v != "" {
+		if !IsValidLogLevel(v) {
+			v = strings.ToLower(v)
+		}
+		if err := checkWriterLevel(v); err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", EnvLevel, err)
+		}
+		level = ToLogLevel(v)
+	}
+
+	format := FormatAnsi
+	if v := strings.ToLower(os.Getenv(EnvFormat)); v != "" {
+		if !IsValidLogFormat(v) {
+			return nil, fmt.Errorf("invalid %s %q", EnvFormat, v)
+		}
+		format = ToLogFormat(v)
+	}
+
+	stream, useStream := StdOut, true
+	switch v := os.Getenv(EnvStream); strings.ToLower(v) {
+	case "", "stdout":
+	case "stderr":
+		stream = StdErr
+	case "none":
+		useStream = false
+	default:
+		return nil, fmt.Errorf("invalid %s %q, expected stdout, stderr or none", EnvStream, v)
+	}
+
+	var httpOpt *ToHttpWriterOptions
+	if v := os.Getenv(EnvHttpURL); v != "" {
+		httpOpt = &ToHttpWriterOptions{URL: v, Level: level}
+		if err := validateToHttpWriterOptions(httpOpt); err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", EnvHttpURL, err)
+		}
+	}
+
+	// everything is validated before any writer starts
+	var writers []Writer
+	if useStream {
+		writers = append(writers, WithStdIoWriter(&ToStdStreamWriterOptions{
+			Level:  level,
+			Format: format,
+			Stream: stream,
+		}))
+	}
+	if v := os.Getenv(EnvFile); v != "" {
+		writers = append(writers, WithToFileWriter(&ToFileWriterOptions{
+			FileName: v,
+			Level:    level,
+		}))
+	}
+	if httpOpt != nil {
+		writers = append(writers, WithToHttpWriter(httpOpt))
+	}
+
+	if len(writers) == 0 {
+		// NewLogger would fall back to stdout
+		return &SLogger{
+			writers: newWriterSet(),
+			Buffer:  NewLogBuffer(1000),
+		}, nil
+	}
+	return NewLogger(writers...)
+}
+
+func defaultLogger() *SLogger {
+	l, err := NewLoggerFromEnv()
+	if err != nil {
+		fmt.Printf("slog: ignoring environment configuration: %v\n", err)
+		l, _ = NewLogger()
+	}
+	return l
+}
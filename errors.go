@@ -0,0 +1,122 @@
+package slog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// maxErrorDepth bounds how far wrapped errors are followed
+const maxErrorDepth = 10
+
+// LogFielder is implemented by errors that carry structured fields worth logging.
+type LogFielder interface {
+	LogFields() map[string]interface{}
+}
+
+// ErrorInfo is how an error arg is serialized: its message, concrete type,
+// structured fields and the errors it wraps.
+type ErrorInfo struct {
+	Msg    string                 `json:"msg"`
+	Type   string                 `json:"type"`
+	Fields map[string]interface{} `json:"fields,omitempty"`
+	Causes []ErrorInfo            `json:"causes,omitempty"`
+}
+
+func NewErrorInfo(err error) ErrorInfo {
+	return newErrorInfo(err, 0)
+}
+
+func newErrorInfo(err error, depth int) ErrorInfo {
+	if isNilError(err) {
+		return ErrorInfo{Msg: "<nil>", Type: fmt.Sprintf("%T", err)}
+	}
+	if re, ok := err.(*redactedError); ok {
+		return re.info
+	}
+
+	info := ErrorInfo{
+		Msg:  err.Error(),
+		Type: fmt.Sprintf("%T", err),
+	}
+
+	if lf, ok := err.(LogFielder); ok {
+		info.Fields = lf.LogFields()
+	}
+
+	if depth >= maxErrorDepth {
+		return info
+	}
+
+	var causes []error
+	switch e := err.(type) {
+	case interface{ Unwrap() []error }:
+		causes = e.Unwrap()
+	default:
+		if cause := errors.Unwrap(err); cause != nil {
+			causes = []error{cause}
+		}
+	}
+
+	for _, cause := range causes {
+		if !isNilError(cause) {
+			info.Causes = append(info.Causes, newErrorInfo(cause, depth+1))
+		}
+	}
+
+	return info
+}
+
+// isNilError reports whether err is nil or a nil pointer (or other nil value) of
+// an error type, e.g. a nil *MyErr passed as an error. Calling Error on those
+// usually panics.
+func isNilError(err error) bool {
+	return isNilValue(err)
+}
+
+// isNilValue reports whether v is nil or holds a nil pointer, map, slice, func,
+// chan or interface
+func isNilValue(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	switch v := reflect.ValueOf(v); v.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan, reflect.Interface:
+		return v.IsNil()
+	default:
+		return false
+	}
+}
+
+// writeErrorArg writes an error arg for text output, with the fields of the error
+// and every error it wraps, and the types of the wrapped errors:
+// key="msg" key.type=T key.<field>=v key.causes=[T1 T2]
+func writeErrorArg(buf *bytes.Buffer, key string, err error) {
+	info := NewErrorInfo(err)
+
+	fmt.Fprintf(buf, " %s=%q %s.type=%s", key, info.Msg, key, info.Type)
+	for _, k := range sortedKeys(info.Fields) {
+		fmt.Fprintf(buf, " %s.%s=%v", key, k, info.Fields[k])
+	}
+
+	if len(info.Causes) > 0 {
+		types := make([]string, 0, len(info.Causes))
+		info.walkCauses(func(c ErrorInfo) {
+			types = append(types, c.Type)
+			for _, k := range sortedKeys(c.Fields) {
+				fmt.Fprintf(buf, " %s.%s=%v", key, k, c.Fields[k])
+			}
+		})
+		fmt.Fprintf(buf, " %s.causes=[%s]", key, strings.Join(types, " "))
+	}
+}
+
+// walkCauses calls f for every wrapped error, depth first
+func (e ErrorInfo) walkCauses(f func(ErrorInfo)) {
+	for _, c := range e.Causes {
+		f(c)
+		c.walkCauses(f)
+	}
+}
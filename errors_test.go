@@ -0,0 +1,59 @@
+package slog
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type testError struct{ msg string }
+
+func (e *testError) Error() string { return e.msg }
+
+func TestTypedNilErrorArg(t *testing.T) {
+	var err *testError
+
+	l := &Log{
+		Type:      "INFO",
+		Level:     InfoLevel,
+		Timestamp: time.Now(),
+		Msg:       "x",
+		Fields:    appendFields(nil, []interface{}{"err", err, Err(err)}),
+	}
+
+	b, encErr := l.Encode(FormatJson)
+	if encErr != nil {
+		t.Fatalf("Encode(json): %v", encErr)
+	}
+	if !strings.Contains(string(b), `"args":{"err":null,"error":null}`) {
+		t.Errorf("json = %s, want nil errors written as null", b)
+	}
+
+	b, encErr = l.Encode(FormatText)
+	if encErr != nil {
+		t.Fatalf("Encode(text): %v", encErr)
+	}
+	if !strings.Contains(string(b), "err=<nil> error=<nil>") {
+		t.Errorf("text = %s, want nil errors written as <nil>", b)
+	}
+
+	if info := NewErrorInfo(err); info.Msg != "<nil>" {
+		t.Errorf("NewErrorInfo(nil *testError).Msg = %q, want <nil>", info.Msg)
+	}
+}
+
+func TestErrorInfoSkipsTypedNilCause(t *testing.T) {
+	var cause *testError
+	info := NewErrorInfo(&wrapError{msg: "outer", err: cause})
+	if len(info.Causes) != 0 {
+		t.Errorf("Causes = %+v, want none", info.Causes)
+	}
+}
+
+type wrapError struct {
+	msg string
+	err error
+}
+
+func (e *wrapError) Error() string { return e.msg }
+func (e *wrapError) Unwrap() error { return e.err }
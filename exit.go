@@ -0,0 +1,121 @@
+package slog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultFlushTimeout bounds how long Fatal and Panic wait for writers to flush.
+const DefaultFlushTimeout = 3 * time.Second
+
+var exitHooks struct {
+	mu    sync.Mutex
+	hooks []func()
+}
+
+// RegisterExitHook adds f to the functions run after writers are flushed and before
+// Fatal exits the process. Hooks run in the order they were registered.
+func RegisterExitHook(f func()) {
+	if f == nil {
+		return
+	}
+	exitHooks.mu.Lock()
+	defer exitHooks.mu.Unlock()
+	exitHooks.hooks = append(exitHooks.hooks, f)
+}
+
+func runExitHooks() {
+	exitHooks.mu.Lock()
+	hooks := exitHooks.hooks
+	exitHooks.mu.Unlock()
+
+	for _, f := range hooks {
+		runExitHook(f)
+	}
+}
+
+func runExitHook(f func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Printf("runExitHook(): exit hook panicked: %v\n", r)
+		}
+	}()
+	f()
+}
+
+// Flush flushes every writer implementing Flusher concurrently, waiting at most timeout.
+func (s *SLogger) Flush(timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs []error
+
+	for _, w := range s.writers.list() {
+		f, ok := w.(Flusher)
+		if !ok {
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := f.Flush(ctx); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+func Flush(timeout time.Duration) error {
+	if Slog == nil {
+		return nil
+	}
+	return Slog.Flush(timeout)
+}
+
+func (s *SLogger) flushTimeout() time.Duration {
+	if s.flushTimeoutDur > 0 {
+		return s.flushTimeoutDur
+	}
+	return DefaultFlushTimeout
+}
+
+// SetFlushTimeout sets how long Fatal and Panic wait for writers to flush.
+func (s *SLogger) SetFlushTimeout(d time.Duration) {
+	s.flushTimeoutDur = d
+}
+
+func SetFlushTimeout(d time.Duration) {
+	if Slog == nil {
+		return
+	}
+	Slog.SetFlushTimeout(d)
+}
+
+// exit flushes the writers, runs the exit hooks and terminates the process
+func (s *SLogger) exit() {
+	if err := s.Flush(s.flushTimeout()); err != nil {
+		fmt.Printf("SLogger.exit(): failed to flush writers: %v\n", err)
+	}
+	runExitHooks()
+	os.Exit(1)
+}
+
+// flushAndPanic flushes the writers and panics with msg
+func (s *SLogger) flushAndPanic(msg string) {
+	if err := s.Flush(s.flushTimeout()); err != nil {
+		fmt.Printf("SLogger.flushAndPanic(): failed to flush writers: %v\n", err)
+	}
+	panic(msg)
+}
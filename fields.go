@@ -0,0 +1,244 @@
+package slog
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+type fieldKind uint8
+
+const (
+	kindAny fieldKind = iota
+	kindString
+	kindInt
+	kindInt64
+	kindFloat64
+	kindBool
+	kindDuration
+	kindError
+	kindGroup
+)
+
+// Field is a single key/value pair of a Log, kept in the order it was passed.
+// Fields built with the typed constructors (String, Int, Dur, ...) store their
+// value unboxed and are encoded without reflection. They can be mixed freely
+// with loose key/value args, though passed as args each Field is boxed like any
+// other arg; SLogger.LogFields takes them without boxing:
+//
+//	slog.Info("request", slog.String("method", "GET"), "status", 200)
+type Field struct {
+	Key  string
+	kind fieldKind
+	num  int64
+	str  string
+	val  interface{}
+}
+
+func String(key string, v string) Field {
+	return Field{Key: key, kind: kindString, str: v}
+}
+
+func Int(key string, v int) Field {
+	return Field{Key: key, kind: kindInt, num: int64(v)}
+}
+
+func Int64(key string, v int64) Field {
+	return Field{Key: key, kind: kindInt64, num: v}
+}
+
+func Float64(key string, v float64) Field {
+	return Field{Key: key, kind: kindFloat64, num: int64(math.Float64bits(v))}
+}
+
+func Bool(key string, v bool) Field {
+	f := Field{Key: key, kind: kindBool}
+	if v {
+		f.num = 1
+	}
+	return f
+}
+
+// Dur is written as a string such as "1.5s" in text and JSON.
+func Dur(key string, v time.Duration) Field {
+	return Field{Key: key, kind: kindDuration, num: int64(v)}
+}
+
+// Err is keyed "error". A nil error is written as null.
+func Err(err error) Field {
+	return Field{Key: "error", kind: kindError, val: err}
+}
+
+func Any(key string, v interface{}) Field {
+	return Field{Key: key, kind: kindAny, val: v}
+}
+
+// Group nests fields under key, written as an object in JSON and as
+// dotted keys (key.sub=v) in text.
+func Group(key string, fields ...Field) Field {
+	return Field{Key: key, kind: kindGroup, val: fields}
+}
+
+// Value returns the field's value boxed in an interface. A Group's value is a map of its fields.
+func (f Field) Value() interface{} {
+	switch f.kind {
+	case kindString:
+		return f.str
+	case kindInt:
+		return int(f.num)
+	case kindInt64:
+		return f.num
+	case kindFloat64:
+		return math.Float64frombits(uint64(f.num))
+	case kindBool:
+		return f.num == 1
+	case kindDuration:
+		return time.Duration(f.num)
+	case kindGroup:
+		return fieldsToMap(f.val.([]Field))
+	default:
+		return f.val
+	}
+}
+
+// LogValuer is implemented by arg values that are expensive to compute.
+// LogValue is only called once a log is known to be written, once per log,
+// and its result is shared by every writer. It may return a Field to use a
+// typed value.
+type LogValuer interface {
+	LogValue() interface{}
+}
+
+// maxLogValuerDepth bounds LogValue calls returning further LogValuers
+const maxLogValuerDepth = 10
+
+// resolveFields replaces LogValuer values with their LogValue, in place
+func resolveFields(fields []Field) {
+	for i := range fields {
+		if f, ok := resolveField(fields[i]); ok {
+			fields[i] = f
+		}
+	}
+}
+
+// resolveField returns f with its LogValuers resolved, and whether anything changed
+func resolveField(f Field) (Field, bool) {
+	changed := false
+
+	switch f.kind {
+	case kindAny:
+		for depth := 0; depth < maxLogValuerDepth; depth++ {
+			lv, ok := f.val.(LogValuer)
+			// a nil pointer would usually make LogValue panic, it is written as nil
+			if !ok || isNilValue(lv) {
+				break
+			}
+
+			changed = true
+			v := logValue(lv)
+			if vf, ok := v.(Field); ok {
+				vf.Key = f.Key
+				f = vf
+				if f.kind != kindAny {
+					f, _ = resolveField(f)
+					break
+				}
+				continue
+			}
+			f.val = v
+		}
+
+	case kindGroup:
+		// groups may be shared between logs through With, so resolve into a copy
+		group := f.val.([]Field)
+		var resolved []Field
+		for i, gf := range group {
+			rf, ok := resolveField(gf)
+			if !ok {
+				continue
+			}
+			if resolved == nil {
+				resolved = append([]Field(nil), group...)
+			}
+			resolved[i] = rf
+		}
+		if resolved != nil {
+			f.val = resolved
+			changed = true
+		}
+	}
+
+	return f, changed
+}
+
+// logValue calls lv.LogValue, returning an error describing the panic instead
+// if it panics, so a broken LogValuer can't crash the caller
+func logValue(lv LogValuer) (v interface{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			v = fmt.Errorf("LogValue panicked: %v", r)
+		}
+	}()
+	return lv.LogValue()
+}
+
+// appendFields pairs up loose key/value args onto fields, taking Field args as they are.
+// A non-string key is named after its position ("arg0", "arg2", ...), and a key
+// repeated later replaces the earlier value while keeping the position of its
+// first occurrence.
+func appendFields(fields []Field, args []interface{}) []Field {
+	for i := 0; i < len(args); i += 2 {
+		if f, ok := args[i].(Field); ok {
+			fields = setField(fields, f)
+			i--
+			continue
+		}
+
+		key, ok := args[i].(string)
+		if !ok {
+			key = fmt.Sprintf("arg%d", i)
+		}
+
+		var value interface{}
+		if i+1 < len(args) {
+			value = args[i+1]
+		}
+
+		fields = setField(fields, Field{Key: key, val: value})
+	}
+
+	return fields
+}
+
+func setField(fields []Field, f Field) []Field {
+	for i := range fields {
+		if fields[i].Key == f.Key {
+			fields[i] = f
+			return fields
+		}
+	}
+	return append(fields, f)
+}
+
+// fieldsToMap is the map view of fields stored in Log.Args
+func fieldsToMap(fields []Field) map[string]interface{} {
+	m := make(map[string]interface{}, len(fields))
+	for _, f := range fields {
+		m[f.Key] = f.Value()
+	}
+	return m
+}
+
+// orderedFields returns the fields of l in call order, falling back to the
+// Args map sorted by key for logs built without Fields
+func (l *Log) orderedFields() []Field {
+	if len(l.Fields) > 0 || len(l.Args) == 0 {
+		return l.Fields
+	}
+
+	fields := make([]Field, 0, len(l.Args))
+	for _, k := range sortedKeys(l.Args) {
+		fields = append(fields, Field{Key: k, val: l.Args[k]})
+	}
+	return fields
+}
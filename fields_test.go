@@ -0,0 +1,43 @@
+package slog
+
+import (
+	"strings"
+	"testing"
+)
+
+type testUser struct{ name string }
+
+func (u *testUser) LogValue() interface{} { return u.name }
+
+type panicValuer struct{}
+
+func (panicValuer) LogValue() interface{} { panic("boom") }
+
+func TestResolveNilLogValuer(t *testing.T) {
+	w := &lastLineWriter{format: FormatJson}
+	l, err := NewLogger(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var u *testUser
+	l.Info("x", "user", u, Any("other", u))
+
+	if !strings.Contains(w.line, `"args":{"user":null,"other":null}`) {
+		t.Errorf("json = %s, want nil LogValuers written as null", w.line)
+	}
+}
+
+func TestResolvePanickingLogValuer(t *testing.T) {
+	w := &lastLineWriter{format: FormatText}
+	l, err := NewLogger(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l.Info("x", "v", panicValuer{})
+
+	if !strings.Contains(w.line, `v="LogValue panicked: boom"`) {
+		t.Errorf("text = %s, want the panic written as the value", w.line)
+	}
+}
@@ -0,0 +1,159 @@
+package slog
+
+import (
+	"context"
+	stdslog "log/slog"
+	"runtime"
+	"strings"
+)
+
+// Handler implements log/slog.Handler and forwards records into an SLogger,
+// so code using the standard library API writes through this package's writers.
+type Handler struct {
+	logger *SLogger
+	attrs  []interface{}
+	groups []string
+}
+
+func NewHandler(l *SLogger) *Handler {
+	if l == nil {
+		l = Slog
+	}
+	return &Handler{logger: l}
+}
+
+func fromStdLevel(l stdslog.Level) LogLevel {
+	switch {
+	case l < stdslog.LevelInfo:
+		return DebugLevel
+	case l < stdslog.LevelWarn:
+		return InfoLevel
+	case l < stdslog.LevelError:
+		return WarnLevel
+	default:
+		return ErrorLevel
+	}
+}
+
+func (h *Handler) Enabled(_ context.Context, l stdslog.Level) bool {
+	if h.logger == nil {
+		return false
+	}
+	return h.logger.Enabled(fromStdLevel(l))
+}
+
+func (h *Handler) Handle(ctx context.Context, r stdslog.Record) error {
+	if h.logger == nil {
+		return nil
+	}
+
+	lvl := fromStdLevel(r.Level)
+	if !h.logger.Enabled(lvl) {
+		return nil
+	}
+	if !h.logger.sampled(lvl, r.Message) {
+		return nil
+	}
+
+	args := make([]interface{}, 0, len(h.attrs)+r.NumAttrs()*2)
+	args = append(args, h.attrs...)
+	prefix := h.prefix()
+	r.Attrs(func(a stdslog.Attr) bool {
+		args = appendAttr(args, prefix, a)
+		return true
+	})
+	args = h.logger.withContext(ctx, args)
+
+	var pcs []uintptr
+	if h.logger.wantStack(lvl) {
+		pcs = stdCallerPCs()
+	} else if h.logger.reportCaller.Load() && r.PC != 0 {
+		// the record already carries the pc of the log/slog call site
+		pcs = []uintptr{r.PC}
+	}
+
+	h.logger.writeLogPCs(pcs, r.Time, lvl, r.Message, nil, args...)
+	return nil
+}
+
+// stdCallerPCs returns the stack above Handle, without the log/slog frames in between
+func stdCallerPCs() []uintptr {
+	// skip stdCallerPCs and Handle
+	pcs := callerPCs(2, maxStackDepth+8)
+	for i, pc := range pcs {
+		// pcs are return addresses, pc-1 is inside the calling function
+		fn := runtime.FuncForPC(pc - 1)
+		if fn != nil && strings.HasPrefix(fn.Name(), "log/slog.") {
+			continue
+		}
+		if len(pcs)-i > maxStackDepth {
+			return pcs[i : i+maxStackDepth]
+		}
+		return pcs[i:]
+	}
+	return nil
+}
+
+func (h *Handler) WithAttrs(attrs []stdslog.Attr) stdslog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+
+	h2 := h.clone()
+	prefix := h.prefix()
+	for _, a := range attrs {
+		h2.attrs = appendAttr(h2.attrs, prefix, a)
+	}
+	return h2
+}
+
+func (h *Handler) WithGroup(name string) stdslog.Handler {
+	if name == "" {
+		return h
+	}
+
+	h2 := h.clone()
+	h2.groups = append(h2.groups, name)
+	return h2
+}
+
+func (h *Handler) clone() *Handler {
+	return &Handler{
+		logger: h.logger,
+		attrs:  append([]interface{}(nil), h.attrs...),
+		groups: append([]string(nil), h.groups...),
+	}
+}
+
+func (h *Handler) prefix() string {
+	if len(h.groups) == 0 {
+		return ""
+	}
+	return strings.Join(h.groups, ".") + "."
+}
+
+// appendAttr flattens a into key/value pairs, joining group names with dots
+func appendAttr(args []interface{}, prefix string, a stdslog.Attr) []interface{} {
+	a.Value = a.Value.Resolve()
+	if a.Equal(stdslog.Attr{}) {
+		return args
+	}
+
+	if a.Value.Kind() == stdslog.KindGroup {
+		attrs := a.Value.Group()
+		if len(attrs) == 0 {
+			return args
+		}
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range attrs {
+			args = appendAttr(args, prefix, ga)
+		}
+		return args
+	}
+
+	return append(args, prefix+a.Key, a.Value.Any())
+}
+
+var _ stdslog.Handler = &Handler{}
@@ -0,0 +1,33 @@
+package slog
+
+import (
+	"context"
+	stdslog "log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandlerUsesRecordTime(t *testing.T) {
+	w := &lastLineWriter{format: FormatJson}
+	l, err := NewLogger(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewHandler(l)
+
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := h.Handle(context.Background(), stdslog.NewRecord(ts, stdslog.LevelInfo, "x", 0)); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(w.line, `"timestamp":"2024-01-02T03:04:05Z"`) {
+		t.Errorf("json = %s, want the record's time", w.line)
+	}
+
+	if err := h.Handle(context.Background(), stdslog.NewRecord(time.Time{}, stdslog.LevelInfo, "x", 0)); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(w.line, `"time"`) || strings.Contains(w.line, `"timestamp"`) {
+		t.Errorf("json = %s, want no time for a zero record time", w.line)
+	}
+}
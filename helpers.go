@@ -4,8 +4,6 @@ import (
 	er "errors"
 	"sync"
 	"time"
-
-	"github.com/mattn/go-runewidth"
 )
 
 func joinError(m string, err error) error {
@@ -37,27 +35,8 @@ const (
 	Truncate = false
 )
 
-func pad(s string) string {
-	paddedString := " " + s
-	width := runewidth.StringWidth(paddedString)
-
-	if width > PadWidth {
-		if Truncate {
-			return runewidth.Truncate(paddedString, PadWidth, "")
-		}
-		return paddedString
-	}
-
-	for width < PadWidth {
-		paddedString += " "
-		width = runewidth.StringWidth(paddedString)
-	}
-
-	return paddedString
-}
-
 func GetTime() string {
-	return time.Now().Format("2006-01-02 15:04:05.000")
+	return time.Now().Format(timeFormat)
 }
 
 type LogBuffer struct {
@@ -74,6 +53,11 @@ func NewLogBuffer(max int) *LogBuffer {
 }
 
 func (b *LogBuffer) Add(log *Log) {
+	b.add(log.Copy())
+}
+
+// add keeps c, which must be a Copy
+func (b *LogBuffer) add(c Log) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
@@ -81,7 +65,7 @@ func (b *LogBuffer) Add(log *Log) {
 		b.buf = b.buf[1:]
 	}
 
-	b.buf = append(b.buf, log.Copy())
+	b.buf = append(b.buf, c)
 }
 
 func (b *LogBuffer) GetLogs(limit int64) []Log {
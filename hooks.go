@@ -0,0 +1,88 @@
+package slog
+
+// Hook inspects every log before it reaches the buffer and writers. Fire may
+// modify the log, e.g. to add fields with SetField, and returns false to drop it.
+type Hook interface {
+	// Levels the hook runs for, or nil for every level
+	Levels() []LogLevel
+	Fire(l *Log) bool
+}
+
+type hookFunc struct {
+	levels []LogLevel
+	fn     func(*Log) bool
+}
+
+// NewHook returns a Hook calling fn for the given levels, or every level if none are given.
+func NewHook(fn func(l *Log) bool, levels ...LogLevel) Hook {
+	return &hookFunc{levels: levels, fn: fn}
+}
+
+func (h *hookFunc) Levels() []LogLevel {
+	return h.levels
+}
+
+func (h *hookFunc) Fire(l *Log) bool {
+	return h.fn(l)
+}
+
+// AddHook registers h. Hooks run in the order they were added, before redaction.
+// It is safe to call while the logger is in use; logs already being written may
+// not see h. Children created with With before the call do not get h.
+func (s *SLogger) AddHook(h Hook) {
+	if h == nil {
+		return
+	}
+
+	// copy-on-write, so runHooks can range over the slice without a lock
+	for {
+		old := s.hooks.Load()
+		var hooks []Hook
+		if old != nil {
+			hooks = make([]Hook, 0, len(*old)+1)
+			hooks = append(hooks, *old...)
+		}
+		hooks = append(hooks, h)
+		if s.hooks.CompareAndSwap(old, &hooks) {
+			return
+		}
+	}
+}
+
+func AddHook(h Hook) {
+	if Slog == nil {
+		return
+	}
+	Slog.AddHook(h)
+}
+
+// SetField sets a field on l, replacing the value of an existing field with the same key.
+func (l *Log) SetField(f Field) {
+	l.Fields = setField(l.Fields, f)
+}
+
+// runHooks reports whether l should still be written after every hook for its level ran
+func runHooks(hooks []Hook, l *Log) bool {
+	for _, h := range hooks {
+		if !hookWantsLevel(h, l.Level) {
+			continue
+		}
+		if !h.Fire(l) {
+			return false
+		}
+	}
+	return true
+}
+
+func hookWantsLevel(h Hook, lvl LogLevel) bool {
+	levels := h.Levels()
+	if len(levels) == 0 {
+		return true
+	}
+	for _, l := range levels {
+		if l == lvl {
+			return true
+		}
+	}
+	return false
+}
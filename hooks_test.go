@@ -0,0 +1,31 @@
+package slog
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestAddHookWhileLogging(t *testing.T) {
+	w := &captureWriter{}
+	l, err := NewLogger(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			l.Info("x")
+		}
+	}()
+	for i := 0; i < 10; i++ {
+		l.AddHook(NewHook(func(l *Log) bool { return true }))
+	}
+	wg.Wait()
+
+	if n := len(*l.hooks.Load()); n != 10 {
+		t.Errorf("got %d hooks, want 10", n)
+	}
+}
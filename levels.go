@@ -0,0 +1,126 @@
+package slog
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// LevelInfo describes a log level: its place in the ordering, the short label
+// written with each log, the long name used by ToLogLevel and in config, and
+// the ANSI color of the label.
+type LevelInfo struct {
+	Level LogLevel
+	Label string // e.g. "WARN"
+	Name  string // e.g. "warn"
+	Color string // e.g. ColorOrange
+}
+
+// levelRegistry is replaced as a whole on every registration, so lookups
+// never take a lock
+type levelRegistry struct {
+	byLevel map[LogLevel]LevelInfo
+	byName  map[string]LevelInfo
+}
+
+var (
+	levelsMu sync.Mutex
+	// initialized by a var rather than init so it is ready for the default logger
+	levels = builtinLevels()
+)
+
+func builtinLevels() *atomic.Pointer[levelRegistry] {
+	r := &levelRegistry{
+		byLevel: make(map[LogLevel]LevelInfo),
+		byName:  make(map[string]LevelInfo),
+	}
+	for _, info := range []LevelInfo{
+		{Level: DebugLevel, Label: "DBUG", Name: string(DebugLevelString), Color: ColorYellow},
+		{Level: InfoLevel, Label: "INFO", Name: string(InfoLevelString), Color: ColorBlue},
+		{Level: WarnLevel, Label: "WARN", Name: string(WarnLevelString), Color: ColorOrange},
+		{Level: ErrorLevel, Label: "EROR", Name: string(ErrorLevelString), Color: ColorRed},
+		{Level: FatalLevel, Label: "FTAL", Name: string(FatalLevelString), Color: ColorPurple},
+		{Level: PanicLevel, Label: "PANC", Name: string(PanicLevelString), Color: ColorPink},
+		{Level: StatLevel, Label: "STAT", Name: string(StatLevelString), Color: ColorGreen},
+	} {
+		r.byLevel[info.Level] = info
+		r.byName[info.Name] = info
+	}
+
+	p := new(atomic.Pointer[levelRegistry])
+	p.Store(r)
+	return p
+}
+
+// RegisterLevel adds a custom level such as TRACE, NOTICE or AUDIT. The built-in
+// levels are spaced ten apart, so e.g. a NOTICE between InfoLevel and WarnLevel
+// could use InfoLevel + 5. Custom levels are written with SLogger.LogAt and can
+// be used anywhere a LogLevel is accepted, including writer levels. A level
+// named in a config file or SLOG_* variable must be registered before the config
+// is loaded; the default logger reads SLOG_LEVEL when the package initializes.
+func RegisterLevel(info LevelInfo) error {
+	if info.Level == 0 {
+		return fmt.Errorf("level value must not be 0")
+	}
+	if info.Label == "" || info.Name == "" {
+		return fmt.Errorf("level %d must have a label and a name", info.Level)
+	}
+
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+
+	old := levels.Load()
+	if existing, ok := old.byLevel[info.Level]; ok {
+		return fmt.Errorf("level %d is already registered as %q", info.Level, existing.Name)
+	}
+	if existing, ok := old.byName[info.Name]; ok {
+		return fmt.Errorf("level name %q is already registered as level %d", info.Name, existing.Level)
+	}
+
+	r := &levelRegistry{
+		byLevel: make(map[LogLevel]LevelInfo, len(old.byLevel)+1),
+		byName:  make(map[string]LevelInfo, len(old.byName)+1),
+	}
+	for l, i := range old.byLevel {
+		r.byLevel[l] = i
+	}
+	for n, i := range old.byName {
+		r.byName[n] = i
+	}
+	r.byLevel[info.Level] = info
+	r.byName[info.Name] = info
+	levels.Store(r)
+	return nil
+}
+
+// Levels returns every registered level, built-in and custom, in ascending order
+func Levels() []LevelInfo {
+	r := levels.Load()
+	infos := make([]LevelInfo, 0, len(r.byLevel))
+	for _, info := range r.byLevel {
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Level < infos[j].Level })
+	return infos
+}
+
+// LookupLevel returns the level registered under name, e.g. "warn" or "audit"
+func LookupLevel(name string) (LevelInfo, bool) {
+	info, ok := levels.Load().byName[name]
+	return info, ok
+}
+
+// Info returns the registered description of l. Unregistered levels are
+// labelled with their value.
+func (l LogLevel) Info() LevelInfo {
+	if info, ok := levels.Load().byLevel[l]; ok {
+		return info
+	}
+	s := fmt.Sprintf("LEVEL(%d)", int(l))
+	return LevelInfo{Level: l, Label: s, Name: s, Color: ColorWhite}
+}
+
+func (l LogLevel) String() string {
+	return l.Info().Name
+}
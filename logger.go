@@ -1,27 +1,26 @@
 package slog
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
-	"os"
 	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
-// set default logger
-var Slog *SLogger = &SLogger{
-	Buffer: NewLogBuffer(1000),
-	writers: []Writer{
-		&toStdStreamWriter{
-			Stream: StdOut,
-			format: FormatAnsi,
-			level:  InfoLevel,
-		},
-	},
-}
+// set default logger, configured from the SLOG_* environment variables (see NewLoggerFromEnv)
+var Slog *SLogger = defaultLogger()
 
+// Log is a single entry. Logs passed to Writer.Write are pooled and reused once
+// Write returns, so writers that keep an entry must keep a Copy.
+//
+// Time and Args are derived from Timestamp and Fields. They are filled in before
+// the log is written, from the copy kept by the buffer.
 type Log struct {
 	Type      string                 `json:"level"`
+	Logger    string                 `json:"logger,omitempty"`
 	Level     LogLevel               `json:"-"`
 	TypeColor string                 `json:"-"`
 	Time      string                 `json:"time"`
@@ -29,24 +28,103 @@ type Log struct {
 	Msg       string                 `json:"msg"`
 	MsgColor  string                 `json:"-"`
 	Args      map[string]interface{} `json:"args"`
-	Str       string                 `json:"-"`
+	Fields    []Field                `json:"-"`
+	Caller    *Caller                `json:"caller,omitempty"`
+	Stack     []Caller               `json:"stack,omitempty"`
+
+	// Deprecated: the ANSI line is encoded lazily, use Encode(FormatAnsi).
+	// Str is only set on logs delivered by the channel writer.
+	Str string `json:"-"`
+
+	// set if the module level of Logger admitted the log, so it is written
+	// even below the levels of the writers
+	moduleOpened bool
+
+	// cached encodings, indexed by encodingIndex
+	enc [numEncodings]*bytes.Buffer
+}
+
+var logPool = sync.Pool{
+	New: func() interface{} {
+		return new(Log)
+	},
+}
+
+func getLog() *Log {
+	return logPool.Get().(*Log)
+}
+
+func putLog(l *Log) {
+	l.releaseEncodings()
+	fields := l.Fields
+	clear(fields)
+	*l = Log{Fields: fields[:0]}
+	logPool.Put(l)
+}
+
+// TimeString returns Time, or Timestamp in the same format if Time is not set,
+// e.g. on a Log built by hand.
+func (l *Log) TimeString() string {
+	if l.Time == "" && !l.Timestamp.IsZero() {
+		return l.Timestamp.Format(timeFormat)
+	}
+	return l.Time
+}
+
+// ArgsMap returns the args of l keyed by name. It returns a new map built from
+// Fields if Args is not set, e.g. on a Log built by hand, and a copy of Args
+// otherwise, so the result can be kept and modified.
+func (l *Log) ArgsMap() map[string]interface{} {
+	if l.Args == nil {
+		return fieldsToMap(l.Fields)
+	}
+
+	args := make(map[string]interface{}, len(l.Args))
+	for k, v := range l.Args {
+		args[k] = v
+	}
+	return args
 }
 
 func (l *Log) Copy() Log {
 	newLog := *l
-	if l.Args != nil {
-		newArgs := make(map[string]interface{}, len(l.Args))
-		for k, v := range l.Args {
-			newArgs[k] = v
-		}
-		newLog.Args = newArgs
+	newLog.enc = [numEncodings]*bytes.Buffer{}
+
+	newLog.Time = l.TimeString()
+	newLog.Args = l.ArgsMap()
+	if l.Fields != nil {
+		newLog.Fields = append([]Field(nil), l.Fields...)
 	}
 	return newLog
 }
 
 type SLogger struct {
-	writers []Writer
+	writers *writerSet
 	Buffer  *LogBuffer
+
+	// fields bound with With(), prepended to the args of every log
+	fields []Field
+
+	// the settings below may change while the logger is in use, so they are
+	// atomic; slices are replaced rather than modified in place
+	extractors atomic.Pointer[[]ContextExtractor]
+	hooks      atomic.Pointer[[]Hook]
+
+	reportCaller atomic.Bool
+	stackLevel   atomic.Int64
+
+	flushTimeoutDur time.Duration
+
+	redactor atomic.Pointer[redactor]
+	sampler  atomic.Pointer[sampler]
+	deduper  atomic.Pointer[deduper]
+
+	// name and module level, set by Named
+	module *module
+
+	// set on loggers created with With or Named, which share the writers of
+	// the logger they were created from
+	child bool
 }
 
 func NewLogger(writers ...Writer) (*SLogger, error) {
@@ -67,11 +145,52 @@ func NewLogger(writers ...Writer) (*SLogger, error) {
 	}
 
 	return &SLogger{
-		writers: writers,
+		writers: newWriterSet(writers...),
 		Buffer:  NewLogBuffer(1000),
 	}, nil
 }
 
+// SetReportCaller enables capturing the file, line and function of the log call.
+func (s *SLogger) SetReportCaller(enabled bool) {
+	s.reportCaller.Store(enabled)
+}
+
+func SetReportCaller(enabled bool) {
+	if Slog == nil {
+		return
+	}
+	Slog.SetReportCaller(enabled)
+}
+
+// SetStackTraceLevel captures the goroutine stack for logs at or above l, e.g. ErrorLevel.
+// A level of 0 disables stack traces.
+func (s *SLogger) SetStackTraceLevel(l LogLevel) {
+	s.stackLevel.Store(int64(l))
+}
+
+func SetStackTraceLevel(l LogLevel) {
+	if Slog == nil {
+		return
+	}
+	Slog.SetStackTraceLevel(l)
+}
+
+func (s *SLogger) wantStack(lvl LogLevel) bool {
+	min := LogLevel(s.stackLevel.Load())
+	return min > 0 && lvl.rank() >= min
+}
+
+// callDepth returns how many frames above the log call need capturing for lvl
+func (s *SLogger) callDepth(lvl LogLevel) int {
+	if s.wantStack(lvl) {
+		return maxStackDepth
+	}
+	if s.reportCaller.Load() {
+		return 1
+	}
+	return 0
+}
+
 func SetLogger(l *SLogger) {
 	if l == nil {
 		return
@@ -83,7 +202,7 @@ func (s *SLogger) AddWriter(w Writer) {
 	if w == nil {
 		return
 	}
-	s.writers = append(s.writers, w)
+	s.writers.add(w)
 }
 
 func AddWriter(w Writer) {
@@ -97,158 +216,347 @@ func AddWriter(w Writer) {
 	Slog.AddWriter(w)
 }
 
+// RemoveWriter detaches w from the logger and reports whether it was attached.
+// The writer is not closed, but no log is being written to it once RemoveWriter
+// returns, so it can be closed right away.
+func (s *SLogger) RemoveWriter(w Writer) bool {
+	if w == nil {
+		return false
+	}
+	return s.writers.remove(w)
+}
+
+func RemoveWriter(w Writer) bool {
+	if Slog == nil {
+		return false
+	}
+	return Slog.RemoveWriter(w)
+}
+
+// ReplaceWriters swaps all writers at once and returns the previous ones, which
+// are left open for the caller to close. Logs already being written to them
+// finish before ReplaceWriters returns.
+func (s *SLogger) ReplaceWriters(writers ...Writer) []Writer {
+	ws := make([]Writer, 0, len(writers))
+	for _, w := range writers {
+		if w != nil {
+			ws = append(ws, w)
+		}
+	}
+	return s.writers.replace(ws)
+}
+
+func ReplaceWriters(writers ...Writer) []Writer {
+	if Slog == nil {
+		return nil
+	}
+	return Slog.ReplaceWriters(writers...)
+}
+
+// SetLevel changes the level of every attached writer that implements LevelSetter.
+func (s *SLogger) SetLevel(l LogLevel) {
+	for _, w := range s.writers.list() {
+		if ls, ok := w.(LevelSetter); ok {
+			ls.SetLevel(l)
+		}
+	}
+	levelsChanged()
+}
+
+func SetLevel(l LogLevel) {
+	if Slog == nil {
+		return
+	}
+	Slog.SetLevel(l)
+}
+
+// Writers returns a snapshot of the attached writers.
+func (s *SLogger) Writers() []Writer {
+	ws := s.writers.list()
+	return append(make([]Writer, 0, len(ws)), ws...)
+}
+
+func Writers() []Writer {
+	if Slog == nil {
+		return nil
+	}
+	return Slog.Writers()
+}
+
+// With returns a child logger that shares the writers and buffer of s and
+// adds the given key/value pairs to every log it writes.
+func (s *SLogger) With(args ...interface{}) *SLogger {
+	fields := make([]Field, 0, len(s.fields)+len(args)/2)
+	fields = append(fields, s.fields...)
+	fields = appendFields(fields, args)
+
+	child := &SLogger{
+		writers: s.writers,
+		Buffer:  s.Buffer,
+		fields:  fields,
+
+		flushTimeoutDur: s.flushTimeoutDur,
+
+		module: s.module,
+
+		child: true,
+	}
+	// the slices are never modified in place, so they can be shared
+	child.extractors.Store(s.extractors.Load())
+	child.hooks.Store(s.hooks.Load())
+	child.reportCaller.Store(s.reportCaller.Load())
+	child.stackLevel.Store(s.stackLevel.Load())
+	child.redactor.Store(s.redactor.Load())
+	child.sampler.Store(s.sampler.Load())
+	child.deduper.Store(s.deduper.Load())
+	return child
+}
+
+func With(args ...interface{}) *SLogger {
+	if Slog == nil {
+		return nil
+	}
+	return Slog.With(args...)
+}
+
 func (s *SLogger) debug(msg string, args ...interface{}) {
-	s.writeLog(DebugLevel, "DBUG", ColorYellow, msg, args...)
+	s.writeLog(DebugLevel, msg, args...)
 }
 
 func (s *SLogger) info(msg string, args ...interface{}) {
-	s.writeLog(InfoLevel, "INFO", ColorBlue, msg, args...)
+	s.writeLog(InfoLevel, msg, args...)
 }
 
 func (s *SLogger) warn(msg string, args ...interface{}) {
-	s.writeLog(WarnLevel, "WARN", ColorOrange, msg, args...)
+	s.writeLog(WarnLevel, msg, args...)
 }
 
 func (s *SLogger) error(msg string, args ...interface{}) {
-	s.writeLog(ErrorLevel, "EROR", ColorRed, msg, args...)
+	s.writeLog(ErrorLevel, msg, args...)
 }
 
 func (s *SLogger) fatal(msg string, args ...interface{}) {
-	s.writeLog(FatalLevel, "FTAL", ColorPurple, msg, args...)
-	os.Exit(1)
+	s.writeLog(FatalLevel, msg, args...)
+	s.exit()
 }
 
 func (s *SLogger) stat(msg string, args ...interface{}) {
-	s.writeLog(InfoLevel, "STAT", ColorGreen, msg, args...)
+	s.writeLog(StatLevel, msg, args...)
 }
 
 func (s *SLogger) panic(msg string, args ...interface{}) {
-	s.writeLog(PanicLevel, "PANC", ColorPink, msg, args...)
-	panic(msg)
+	s.writeLog(PanicLevel, msg, args...)
+	s.flushAndPanic(msg)
+}
+
+func (s *SLogger) logAt(lvl LogLevel, msg string, args ...interface{}) {
+	s.writeLog(lvl, msg, args...)
+}
+
+func (s *SLogger) logFields(lvl LogLevel, msg string, fields ...Field) {
+	s.writeLogFields(lvl, msg, fields)
 }
 
 func (s *SLogger) debugF(format string, formatArgs []interface{}, args ...interface{}) {
+	if !s.Enabled(DebugLevel) {
+		return
+	}
 	formattedMsg := toFormatStr(format, formatArgs)
-	s.debug(formattedMsg, args...)
+	s.writeLog(DebugLevel, formattedMsg, args...)
 }
 
 func (s *SLogger) infoF(format string, formatArgs []interface{}, args ...interface{}) {
+	if !s.Enabled(InfoLevel) {
+		return
+	}
 	formattedMsg := toFormatStr(format, formatArgs)
-	s.info(formattedMsg, args...)
+	s.writeLog(InfoLevel, formattedMsg, args...)
 }
 
 func (s *SLogger) warnF(format string, formatArgs []interface{}, args ...interface{}) {
+	if !s.Enabled(WarnLevel) {
+		return
+	}
 	formattedMsg := toFormatStr(format, formatArgs)
-	s.warn(formattedMsg, args...)
+	s.writeLog(WarnLevel, formattedMsg, args...)
 }
 
 func (s *SLogger) errorF(format string, formatArgs []interface{}, args ...interface{}) {
+	if !s.Enabled(ErrorLevel) {
+		return
+	}
 	formattedMsg := toFormatStr(format, formatArgs)
-	s.error(formattedMsg, args...)
+	s.writeLog(ErrorLevel, formattedMsg, args...)
 }
 
 func (s *SLogger) fatalF(format string, formatArgs []interface{}, args ...interface{}) {
 	formattedMsg := toFormatStr(format, formatArgs)
-	s.fatal(formattedMsg, args...)
+	s.writeLog(FatalLevel, formattedMsg, args...)
+	s.exit()
 }
 
 func (s *SLogger) panicF(format string, formatArgs []interface{}, args ...interface{}) {
 	formattedMsg := toFormatStr(format, formatArgs)
-	s.panic(formattedMsg, args...)
+	s.writeLog(PanicLevel, formattedMsg, args...)
+	s.flushAndPanic(formattedMsg)
 }
 
-func (s *SLogger) writeLog(lvl LogLevel, t string, c string, msg string, args ...interface{}) {
-	log := &Log{
-		Level:     lvl,
-		Type:      t,
-		TypeColor: c,
-		Time:      GetTime(),
-		Timestamp: time.Now(),
-		Msg:       msg,
-		Args:      toArgsMap(args),
+func (s *SLogger) logAtF(lvl LogLevel, format string, formatArgs []interface{}, args ...interface{}) {
+	if !s.Enabled(lvl) {
+		return
 	}
-	log.Str = s.toString(log)
-	s.write(log)
+	formattedMsg := toFormatStr(format, formatArgs)
+	s.writeLog(lvl, formattedMsg, args...)
 }
 
-func toArgsMap(args []interface{}) map[string]interface{} {
-	m := make(map[string]interface{}, len(args)/2)
-	keys := make([]string, 0, len(args)/2)
+// Enabled reports whether any writer would emit a log at lvl, so callers can
+// skip computing expensive args.
+func (s *SLogger) Enabled(lvl LogLevel) bool {
+	return s.writers.enabled(lvl, s.module)
+}
 
-	for i := 0; i < len(args); i += 2 {
-		key, ok := args[i].(string)
-		if !ok {
-			key = fmt.Sprintf("arg%d", i)
-		}
-		keys = append(keys, key)
+func Enabled(lvl LogLevel) bool {
+	if Slog == nil {
+		return false
+	}
+	return Slog.Enabled(lvl)
+}
 
-		var value interface{}
-		if i+1 < len(args) {
-			value = args[i+1]
-		} else {
-			value = nil
-		}
-		m[key] = value
+// writeLog must be called directly from the lowercase level helpers (info, infoF, infoCtx, ...),
+// which are in turn called directly by the exported functions, so the caller is always callerSkip frames up.
+func (s *SLogger) writeLog(lvl LogLevel, msg string, args ...interface{}) {
+	if !s.Enabled(lvl) {
+		return
+	}
+	if !s.sampled(lvl, msg) {
+		return
+	}
+
+	var pcs []uintptr
+	if n := s.callDepth(lvl); n > 0 {
+		pcs = callerPCs(callerSkip, n)
 	}
+	s.writeLogPCs(pcs, time.Now(), lvl, msg, nil, args...)
+}
 
-	ordered := make(map[string]interface{}, len(keys))
-	for _, key := range keys {
-		ordered[key] = m[key]
+// writeLogFields is writeLog for typed fields, which are appended without being
+// boxed into args. It has the same call depth requirements as writeLog.
+func (s *SLogger) writeLogFields(lvl LogLevel, msg string, fields []Field) {
+	if !s.Enabled(lvl) {
+		return
+	}
+	if !s.sampled(lvl, msg) {
+		return
 	}
 
-	return ordered
+	var pcs []uintptr
+	if n := s.callDepth(lvl); n > 0 {
+		pcs = callerPCs(callerSkip, n)
+	}
+	s.writeLogPCs(pcs, time.Now(), lvl, msg, fields)
+}
+
+// writeLogPCs writes a log made at ts whose call site is pcs[0], followed by the
+// rest of the stack if captured. A zero ts leaves the time out of the output.
+func (s *SLogger) writeLogPCs(pcs []uintptr, ts time.Time, lvl LogLevel, msg string, fields []Field, args ...interface{}) {
+	info := lvl.Info()
+	log := getLog()
+	log.Level = lvl
+	log.Type = info.Label
+	log.TypeColor = info.Color
+	if s.module != nil {
+		log.Logger = s.module.name
+		log.moduleOpened = s.module.opens(lvl)
+	}
+	log.Timestamp = ts
+	log.Msg = msg
+	log.Fields = append(log.Fields, s.fields...)
+	for _, f := range fields {
+		log.Fields = setField(log.Fields, f)
+	}
+	log.Fields = appendFields(log.Fields, args)
+	resolveFields(log.Fields)
+	if s.reportCaller.Load() && len(pcs) > 0 {
+		log.Caller = toCaller(pcs[0])
+	}
+	if s.wantStack(lvl) {
+		log.Stack = toStack(pcs)
+	}
+
+	if hooks := s.hooks.Load(); hooks != nil && !runHooks(*hooks, log) {
+		putLog(log)
+		return
+	}
+	// after hooks, so fields they add are redacted too
+	if r := s.redactor.Load(); r != nil {
+		r.redact(log)
+	}
+
+	s.write(log)
+	putLog(log)
 }
 
 func (s *SLogger) write(l *Log) {
 	if l == nil {
 		return
 	}
+	if d := s.deduper.Load(); d != nil && !d.admit(l) {
+		return
+	}
+	s.writeEntry(l)
+}
+
+// writeEntry adds l to the buffer and passes it to every writer at or below its level
+func (s *SLogger) writeEntry(l *Log) {
+	// the writers share the Time and Args built for the buffer's copy
+	c := l.Copy()
+	l.Time, l.Args = c.Time, c.Args
+	s.Buffer.add(c)
 
-	s.Buffer.Add(l)
-	for _, w := range s.writers {
+	sn := s.writers.acquire()
+	defer sn.release()
+	for _, w := range sn.writers {
 		if w == nil {
-			fmt.Printf("SLogger.write(): writer is nil\n")
+			fmt.Printf("SLogger.writeEntry(): writer is nil\n")
 			continue
 		}
 
-		if l.Level < w.Level() {
+		if !writerAcceptsLog(w, l) {
 			continue
 		}
 
 		err := w.Write(l)
 		if err != nil {
-			fmt.Printf("SLogger.write(): failed to write log entry: %v\n", err)
+			fmt.Printf("SLogger.writeEntry(): failed to write log entry: %v\n", err)
 		}
 	}
 }
 
-func (s *SLogger) toString(log *Log) string {
-	if log == nil {
-		return ""
-	}
-
-	logStr := FontBold + log.TypeColor + "[" + log.Type + "]" + FontNormal
-	logStr += ColorWhite + " [" + log.Time + "]"
-	logStr += pad(log.Msg) + ColorWhite
-
-	if len(log.Args) > 0 {
-		keys := make([]string, 0, len(log.Args))
-		for k := range log.Args {
-			keys = append(keys, k)
-		}
-		sort.Strings(keys)
-
-		for _, k := range keys {
-			logStr += fmt.Sprintf(" %s=%v", k, log.Args[k])
-		}
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
 	}
-
-	logStr += "\n"
-	return logStr
+	sort.Strings(keys)
+	return keys
 }
 
+// Close stops sampling, flushes pending dedup summaries and closes the writers.
+// On a logger created with With or Named it only stops sampling set on that
+// logger itself: the writers and any inherited sampling belong to the logger it
+// was created from, and stay open.
 func (s *SLogger) Close() {
-	for _, w := range s.writers {
+	if sm := s.sampler.Load(); sm != nil && sm.owner == s {
+		sm.close()
+	}
+	if s.child {
+		return
+	}
+	if d := s.deduper.Load(); d != nil {
+		d.flush()
+	}
+
+	for _, w := range s.writers.list() {
 		if w == nil {
 			continue
 		}
@@ -256,6 +564,75 @@ func (s *SLogger) Close() {
 	}
 }
 
+func (s *SLogger) Debug(msg string, args ...interface{}) {
+	s.debug(msg, args...)
+}
+
+func (s *SLogger) Info(msg string, args ...interface{}) {
+	s.info(msg, args...)
+}
+
+func (s *SLogger) Warn(msg string, args ...interface{}) {
+	s.warn(msg, args...)
+}
+
+func (s *SLogger) Error(msg string, args ...interface{}) {
+	s.error(msg, args...)
+}
+
+func (s *SLogger) Fatal(msg string, args ...interface{}) {
+	s.fatal(msg, args...)
+}
+
+func (s *SLogger) Stat(msg string, args ...interface{}) {
+	s.stat(msg, args...)
+}
+
+func (s *SLogger) Panic(msg string, args ...interface{}) {
+	s.panic(msg, args...)
+}
+
+// LogAt writes a log at lvl, which may be a custom level (see RegisterLevel).
+// It does not exit or panic at FatalLevel or PanicLevel; use Fatal and Panic for that.
+func (s *SLogger) LogAt(lvl LogLevel, msg string, args ...interface{}) {
+	s.logAt(lvl, msg, args...)
+}
+
+// LogFields writes a log at lvl with typed fields only. Unlike fields passed
+// as args, they are not boxed into interfaces, so nothing is allocated for them.
+// Like LogAt, it does not exit or panic at FatalLevel or PanicLevel.
+func (s *SLogger) LogFields(lvl LogLevel, msg string, fields ...Field) {
+	s.logFields(lvl, msg, fields...)
+}
+
+func (s *SLogger) DebugF(format string, formatArgs []interface{}, args ...interface{}) {
+	s.debugF(format, formatArgs, args...)
+}
+
+func (s *SLogger) InfoF(format string, formatArgs []interface{}, args ...interface{}) {
+	s.infoF(format, formatArgs, args...)
+}
+
+func (s *SLogger) WarnF(format string, formatArgs []interface{}, args ...interface{}) {
+	s.warnF(format, formatArgs, args...)
+}
+
+func (s *SLogger) ErrorF(format string, formatArgs []interface{}, args ...interface{}) {
+	s.errorF(format, formatArgs, args...)
+}
+
+func (s *SLogger) FatalF(format string, formatArgs []interface{}, args ...interface{}) {
+	s.fatalF(format, formatArgs, args...)
+}
+
+func (s *SLogger) PanicF(format string, formatArgs []interface{}, args ...interface{}) {
+	s.panicF(format, formatArgs, args...)
+}
+
+func (s *SLogger) LogAtF(lvl LogLevel, format string, formatArgs []interface{}, args ...interface{}) {
+	s.logAtF(lvl, format, formatArgs, args...)
+}
+
 func Close() {
 	if Slog == nil {
 		return
@@ -362,3 +739,24 @@ func PanicF(format string, formatArgs []interface{}, args ...interface{}) {
 	}
 	Slog.panicF(format, formatArgs, args...)
 }
+
+func LogAt(lvl LogLevel, msg string, args ...interface{}) {
+	if Slog == nil {
+		return
+	}
+	Slog.logAt(lvl, msg, args...)
+}
+
+func LogAtF(lvl LogLevel, format string, formatArgs []interface{}, args ...interface{}) {
+	if Slog == nil {
+		return
+	}
+	Slog.logAtF(lvl, format, formatArgs, args...)
+}
+
+func LogFields(lvl LogLevel, msg string, fields ...Field) {
+	if Slog == nil {
+		return
+	}
+	Slog.logFields(lvl, msg, fields...)
+}
@@ -0,0 +1,133 @@
+package slog
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type recordWriter struct {
+	time string
+	args map[string]interface{}
+}
+
+func (w *recordWriter) Level() LogLevel { return DebugLevel }
+func (w *recordWriter) Close()          {}
+
+func (w *recordWriter) Write(l *Log) error {
+	// Time and Args are set on written logs, for writers predating the accessors
+	w.time = l.Time
+	w.args = l.Args
+	return nil
+}
+
+func TestWriterSeesTimeAndArgs(t *testing.T) {
+	w := &recordWriter{}
+	l, err := NewLogger(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l.Info("x", "k", 1, String("s", "v"))
+
+	if _, err := time.Parse(timeFormat, w.time); err != nil {
+		t.Errorf("Time = %q, want a %q time: %v", w.time, timeFormat, err)
+	}
+	if w.args["k"] != 1 || w.args["s"] != "v" || len(w.args) != 2 {
+		t.Errorf("Args = %v, want map[k:1 s:v]", w.args)
+	}
+}
+
+func TestAccessorsOnHandBuiltLog(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	l := &Log{Timestamp: ts, Fields: []Field{Int("k", 1)}}
+
+	if got, want := l.TimeString(), ts.Format(timeFormat); got != want {
+		t.Errorf("TimeString() = %q, want %q", got, want)
+	}
+	if args := l.ArgsMap(); args["k"] != 1 || len(args) != 1 {
+		t.Errorf("ArgsMap() = %v, want map[k:1]", args)
+	}
+}
+
+// captureWriter keeps a copy of every log written to it
+type captureWriter struct {
+	logs []Log
+}
+
+func (w *captureWriter) Level() LogLevel { return DebugLevel }
+func (w *captureWriter) Close()          {}
+
+func (w *captureWriter) Write(l *Log) error {
+	w.logs = append(w.logs, l.Copy())
+	return nil
+}
+
+func TestLogFields(t *testing.T) {
+	w := &captureWriter{}
+	l, err := NewLogger(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.SetReportCaller(true)
+
+	l.With("a", 1).LogFields(WarnLevel, "x", String("b", "v"), Int("a", 2))
+
+	if len(w.logs) != 1 {
+		t.Fatalf("wrote %d logs, want 1", len(w.logs))
+	}
+	got := w.logs[0]
+	if got.Level != WarnLevel {
+		t.Errorf("Level = %v, want warn", got.Level)
+	}
+	if len(got.Fields) != 2 || got.Fields[0].Key != "a" || got.Args["a"] != 2 || got.Args["b"] != "v" {
+		t.Errorf("Fields = %+v, want a=2 b=v in that order", got.Fields)
+	}
+	if got.Caller == nil || !strings.HasSuffix(got.Caller.File, "logger_test.go") {
+		t.Errorf("Caller = %+v, want the test", got.Caller)
+	}
+}
+
+func TestLogFieldsAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocation counts vary under the race detector")
+	}
+	l, err := NewLogger(&recordWriter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// package variables, so the loose args are really boxed (see bench_test.go)
+	method, status := benchMethod, benchStatus
+
+	typed := testing.AllocsPerRun(100, func() {
+		l.LogFields(InfoLevel, "x", String("method", method), Int("status", status))
+	})
+	loose := testing.AllocsPerRun(100, func() {
+		l.Info("x", "method", method, "status", status)
+	})
+	if typed > loose {
+		t.Errorf("LogFields made %v allocs, want no more than the %v of loose args", typed, loose)
+	}
+}
+
+func TestWithCloseKeepsParentWriters(t *testing.T) {
+	w := &closeCountWriter{}
+	parent, err := NewLogger(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parent.With("k", "v").Close()
+	if w.closes != 0 {
+		t.Errorf("closing a With child closed the writer %d times, want 0", w.closes)
+	}
+	parent.Info("x")
+	if w.writes != 1 {
+		t.Errorf("parent wrote %d logs after the child was closed, want 1", w.writes)
+	}
+
+	parent.Close()
+	if w.closes != 1 {
+		t.Errorf("closing the parent closed the writer %d times, want 1", w.closes)
+	}
+}
@@ -0,0 +1,155 @@
+package slog
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// moduleLevels holds the minimum level of each named logger, keyed by dotted
+// name, with "*" as the fallback. It is replaced as a whole by SetModuleLevels.
+var moduleLevels atomic.Pointer[map[string]LogLevel]
+
+// SetModuleLevels sets the minimum level of named loggers from a spec such as
+// "db=debug,http.client=warn,*=info". A logger uses the entry for its own name,
+// else for the closest parent (http.client.pool uses http.client), else "*".
+//
+// Module levels are checked before the levels of the writers. An entry for the
+// name of a logger or a parent replaces the writers' levels for it, so db=debug
+// writes the debug logs of the db logger to every writer, except those taking
+// only stats. The "*" entry can only narrow what writers receive, so it does not
+// override writers that are set above it. An empty spec removes every module level.
+func SetModuleLevels(spec string) error {
+	levels, err := parseModuleLevels(spec)
+	if err != nil {
+		return err
+	}
+
+	if len(levels) == 0 {
+		moduleLevels.Store(nil)
+	} else {
+		moduleLevels.Store(&levels)
+	}
+	levelsChanged()
+	return nil
+}
+
+func parseModuleLevels(spec string) (map[string]LogLevel, error) {
+	levels := make(map[string]LogLevel)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, level, ok := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		level = strings.TrimSpace(level)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid module level %q, expected name=level", entry)
+		}
+
+		info, ok := LookupLevel(level)
+		if !ok {
+			return nil, fmt.Errorf("invalid level %q for module %q", level, name)
+		}
+		levels[name] = info.Level
+	}
+	return levels, nil
+}
+
+// moduleLevel returns the level configured for the logger name, or 0 if none
+// applies, and whether it was set for the name or a parent rather than by "*"
+func moduleLevel(name string) (LogLevel, bool) {
+	levels := moduleLevels.Load()
+	if levels == nil {
+		return 0, false
+	}
+
+	for name != "" {
+		if l, ok := (*levels)[name]; ok {
+			return l, true
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+	return (*levels)["*"], false
+}
+
+// module is the name of a logger and its cached module level, shared by the
+// logger and the children created from it with With
+type module struct {
+	name string
+
+	// valid while gen == levelGen+1: the module level, 0 if none applies, and
+	// whether it was set for the name, see moduleLevel
+	min      atomic.Int64
+	explicit atomic.Bool
+	gen      atomic.Uint64
+}
+
+// root is used by loggers that were never named
+var root = &module{}
+
+// allows reports whether the module level of m permits lvl. A nil m is the
+// root of unnamed loggers.
+func (m *module) allows(lvl LogLevel) bool {
+	if m == nil {
+		m = root
+	}
+	min, _ := m.level()
+	return min == 0 || lvl.rank() >= min
+}
+
+// opens reports whether a module level set for the name of m admits lvl, which
+// is then written even below the levels of the writers. Stats are left to the
+// writers' StatModes.
+func (m *module) opens(lvl LogLevel) bool {
+	if m == nil || lvl == StatLevel {
+		return false
+	}
+	min, explicit := m.level()
+	return explicit && lvl >= min
+}
+
+func (m *module) level() (LogLevel, bool) {
+	gen := levelGen.Load()
+	if m.gen.Load() != gen+1 {
+		min, explicit := moduleLevel(m.name)
+		m.min.Store(int64(min))
+		m.explicit.Store(explicit)
+		m.gen.Store(gen + 1)
+	}
+	return LogLevel(m.min.Load()), m.explicit.Load()
+}
+
+// Named returns a child logger for a subsystem. Names nest with dots, so
+// Named("http").Named("client") is "http.client". The name is written with
+// every log as its logger field, and selects the level set by SetModuleLevels.
+func (s *SLogger) Named(name string) *SLogger {
+	if s.module != nil && s.module.name != "" {
+		name = s.module.name + "." + name
+	}
+
+	child := s.With()
+	child.module = &module{name: name}
+	return child
+}
+
+func Named(name string) *SLogger {
+	if Slog == nil {
+		return nil
+	}
+	return Slog.Named(name)
+}
+
+// Name returns the dotted name of the logger, or "" if it was never named
+func (s *SLogger) Name() string {
+	if s.module == nil {
+		return ""
+	}
+	return s.module.name
+}
@@ -0,0 +1,104 @@
+package slog
+
+import "testing"
+
+func TestModuleLevels(t *testing.T) {
+	w := &captureWriter{}
+	info := &levelWriter{captureWriter: w, level: InfoLevel}
+	stats := &levelWriter{captureWriter: &captureWriter{}, level: InfoLevel, stats: StatsOnly}
+	l, err := NewLogger(info, stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SetModuleLevels("db=debug,http.client=warn,*=info"); err != nil {
+		t.Fatal(err)
+	}
+	defer SetModuleLevels("")
+
+	db := l.Named("db")
+	client := l.Named("http").Named("client")
+
+	tests := []struct {
+		name   string
+		logger *SLogger
+		lvl    LogLevel
+		want   bool
+	}{
+		{"module opens below writer", db, DebugLevel, true},
+		{"child module inherits", db.Named("pool"), DebugLevel, true},
+		{"module narrows", client, InfoLevel, false},
+		{"module allows", client, WarnLevel, true},
+		{"star does not open", l.Named("cache"), DebugLevel, false},
+		{"unnamed uses star", l, InfoLevel, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w.logs = nil
+			if got := tt.logger.Enabled(tt.lvl); got != tt.want {
+				t.Errorf("Enabled(%v) = %v, want %v", tt.lvl, got, tt.want)
+			}
+			tt.logger.LogAt(tt.lvl, "x")
+			if got := len(w.logs) == 1; got != tt.want {
+				t.Errorf("written = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if n := len(stats.logs); n != 0 {
+		t.Errorf("stats only writer got %d logs, want 0", n)
+	}
+}
+
+// levelWriter is a captureWriter with a level and stat mode
+type levelWriter struct {
+	*captureWriter
+	level LogLevel
+	stats StatMode
+}
+
+func (w *levelWriter) Level() LogLevel    { return w.level }
+func (w *levelWriter) StatMode() StatMode { return w.stats }
+
+func TestModuleLevelOpensBuiltinWriter(t *testing.T) {
+	ch := make(chan *Log, 1)
+	l, err := NewLogger(WithToChanWriter(&ToChanWriterOptions{Level: InfoLevel, Ch: ch}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SetModuleLevels("db=debug"); err != nil {
+		t.Fatal(err)
+	}
+	defer SetModuleLevels("")
+
+	l.Named("db").Debug("query planned")
+
+	select {
+	case log := <-ch:
+		if log.Msg != "query planned" {
+			t.Errorf("Msg = %q, want query planned", log.Msg)
+		}
+	default:
+		t.Error("debug log of db not written to a writer at info")
+	}
+}
+
+func TestNamedCloseKeepsParentWriters(t *testing.T) {
+	w := &closeCountWriter{}
+	parent, err := NewLogger(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer parent.Close()
+
+	parent.Named("db").Named("pool").Close()
+	if w.closes != 0 {
+		t.Errorf("closing a named logger closed the writer %d times, want 0", w.closes)
+	}
+	parent.Info("x")
+	if w.writes != 1 {
+		t.Errorf("parent wrote %d logs after the named logger was closed, want 1", w.writes)
+	}
+}
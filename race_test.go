@@ -0,0 +1,6 @@
+//go:build race
+
+package slog
+
+// the race detector makes sync.Pool drop items at random, so allocation counts vary
+const raceEnabled = true
@@ -0,0 +1,516 @@
+package slog
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const DefaultRedactMask = "[REDACTED]"
+
+// value patterns for common secrets, for use in RedactOptions.ValuePatterns
+const (
+	RedactJWTPattern        = `eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`
+	RedactCardNumberPattern = `\b(?:\d[ -]?){12,18}\d\b`
+	RedactBearerPattern     = `(?i)bearer\s+[A-Za-z0-9._~+/-]+=*`
+)
+
+type RedactOptions struct {
+	// Keys are arg keys whose values are masked, matched case-insensitively
+	Keys []string
+	// KeyPatterns are glob patterns (path.Match syntax) matched against arg keys,
+	// case-insensitively, e.g. "*token*" or "x-api-*"
+	KeyPatterns []string
+	// ValuePatterns are regular expressions masked wherever they match in the
+	// message and in string values
+	ValuePatterns []string
+	// Mask replaces redacted values, DefaultRedactMask if empty
+	Mask string
+}
+
+type redactor struct {
+	keys        map[string]bool
+	keyPatterns []string
+	values      []*regexp.Regexp
+	mask        string
+}
+
+func newRedactor(opt *RedactOptions) (*redactor, error) {
+	r := &redactor{
+		keys: make(map[string]bool, len(opt.Keys)),
+		mask: opt.Mask,
+	}
+	if r.mask == "" {
+		r.mask = DefaultRedactMask
+	}
+
+	for _, k := range opt.Keys {
+		r.keys[strings.ToLower(k)] = true
+	}
+
+	for _, p := range opt.KeyPatterns {
+		p = strings.ToLower(p)
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid key pattern %q: %v", p, err)
+		}
+		r.keyPatterns = append(r.keyPatterns, p)
+	}
+
+	for _, p := range opt.ValuePatterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value pattern %q: %v", p, err)
+		}
+		r.values = append(r.values, re)
+	}
+
+	return r, nil
+}
+
+// SetRedaction masks sensitive keys and values in every log before it reaches the
+// buffer or any writer. A nil opt disables redaction.
+func (s *SLogger) SetRedaction(opt *RedactOptions) error {
+	if opt == nil {
+		s.redactor.Store(nil)
+		return nil
+	}
+
+	r, err := newRedactor(opt)
+	if err != nil {
+		return joinError("SLogger.SetRedaction()", err)
+	}
+	s.redactor.Store(r)
+	return nil
+}
+
+func SetRedaction(opt *RedactOptions) error {
+	if Slog == nil {
+		return nil
+	}
+	return Slog.SetRedaction(opt)
+}
+
+// matchKey reports whether key, or the last segment of a dotted key such as
+// "headers.authorization" written by the Handler for groups, is sensitive
+func (r *redactor) matchKey(key string) bool {
+	key = strings.ToLower(key)
+	if r.matchName(key) {
+		return true
+	}
+	if i := strings.LastIndexByte(key, '.'); i >= 0 {
+		return r.matchName(key[i+1:])
+	}
+	return false
+}
+
+func (r *redactor) matchName(key string) bool {
+	if r.keys[key] {
+		return true
+	}
+	for _, p := range r.keyPatterns {
+		if ok, _ := path.Match(p, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// maskString returns the mask of r, DefaultRedactMask for a nil redactor
+func (r *redactor) maskString() string {
+	if r == nil {
+		return DefaultRedactMask
+	}
+	return r.mask
+}
+
+func (r *redactor) redactString(s string) string {
+	for _, re := range r.values {
+		s = re.ReplaceAllString(s, r.mask)
+	}
+	return s
+}
+
+func (r *redactor) redact(l *Log) {
+	l.Msg = r.redactString(l.Msg)
+	for i := range l.Fields {
+		if f, ok := r.redactField(l.Fields[i]); ok {
+			l.Fields[i] = f
+		}
+	}
+}
+
+// redactField returns f with sensitive keys and values masked, and whether anything changed
+func (r *redactor) redactField(f Field) (Field, bool) {
+	if r.matchKey(f.Key) {
+		return String(f.Key, r.mask), true
+	}
+
+	switch f.kind {
+	case kindString:
+		if s := r.redactString(f.str); s != f.str {
+			return String(f.Key, s), true
+		}
+
+	case kindGroup:
+		// groups may be shared between logs through With, so redact into a copy
+		group := f.val.([]Field)
+		var redacted []Field
+		for i, gf := range group {
+			rf, ok := r.redactField(gf)
+			if !ok {
+				continue
+			}
+			if redacted == nil {
+				redacted = append([]Field(nil), group...)
+			}
+			redacted[i] = rf
+		}
+		if redacted != nil {
+			f.val = redacted
+			return f, true
+		}
+
+	case kindAny, kindError:
+		if v, ok := r.redactValue(f.val); ok {
+			f.val = v
+			return f, true
+		}
+	}
+
+	return f, false
+}
+
+// redactValue masks strings, errors, the entries of string keyed maps such as
+// http.Header and the fields of structs
+func (r *redactor) redactValue(v interface{}) (interface{}, bool) {
+	switch v := v.(type) {
+	case nil:
+
+	case string:
+		if s := r.redactString(v); s != v {
+			return s, true
+		}
+
+	case error:
+		if isNilError(v) {
+			break
+		}
+		if info, ok := r.redactErrorInfo(NewErrorInfo(v)); ok {
+			return &redactedError{info: info}, true
+		}
+
+	case map[string]string:
+		var m map[string]string
+		for k, s := range v {
+			var rs string
+			if r.matchKey(k) {
+				rs = r.mask
+			} else {
+				rs = r.redactString(s)
+			}
+			if rs == s {
+				continue
+			}
+			if m == nil {
+				m = make(map[string]string, len(v))
+				for k2, s2 := range v {
+					m[k2] = s2
+				}
+			}
+			m[k] = rs
+		}
+		if m != nil {
+			return m, true
+		}
+
+	case http.Header:
+		if m, ok := r.redactValue(map[string][]string(v)); ok {
+			return http.Header(m.(map[string][]string)), true
+		}
+
+	case map[string][]string:
+		var m map[string][]string
+		for k, ss := range v {
+			var rss []string
+			for i, s := range ss {
+				var rs string
+				if r.matchKey(k) {
+					rs = r.mask
+				} else {
+					rs = r.redactString(s)
+				}
+				if rs == s {
+					continue
+				}
+				if rss == nil {
+					rss = append([]string(nil), ss...)
+				}
+				rss[i] = rs
+			}
+			if rss == nil {
+				continue
+			}
+			if m == nil {
+				m = make(map[string][]string, len(v))
+				for k2, ss2 := range v {
+					m[k2] = ss2
+				}
+			}
+			m[k] = rss
+		}
+		if m != nil {
+			return m, true
+		}
+
+	case map[string]interface{}:
+		var m map[string]interface{}
+		for k, x := range v {
+			var rx interface{}
+			var changed bool
+			if r.matchKey(k) {
+				rx, changed = r.mask, true
+			} else {
+				rx, changed = r.redactValue(x)
+			}
+			if !changed {
+				continue
+			}
+			if m == nil {
+				m = make(map[string]interface{}, len(v))
+				for k2, x2 := range v {
+					m[k2] = x2
+				}
+			}
+			m[k] = rx
+		}
+		if m != nil {
+			return m, true
+		}
+
+	default:
+		switch reflect.TypeOf(v).Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Struct, reflect.Slice, reflect.Array, reflect.Map:
+			return r.redactReflect(reflect.ValueOf(v), 0)
+		}
+	}
+
+	return v, false
+}
+
+// redactedError replaces an error arg whose message, fields or causes were
+// redacted. It is written like the original error, from its redacted ErrorInfo.
+type redactedError struct {
+	info ErrorInfo
+}
+
+func (e *redactedError) Error() string {
+	return e.info.Msg
+}
+
+// redactErrorInfo masks the message and fields of info and of every error it wraps
+func (r *redactor) redactErrorInfo(info ErrorInfo) (ErrorInfo, bool) {
+	changed := false
+	if msg := r.redactString(info.Msg); msg != info.Msg {
+		info.Msg, changed = msg, true
+	}
+	if fields, ok := r.redactValue(info.Fields); ok {
+		info.Fields, changed = fields.(map[string]interface{}), true
+	}
+
+	var causes []ErrorInfo
+	for i, c := range info.Causes {
+		rc, ok := r.redactErrorInfo(c)
+		if !ok {
+			continue
+		}
+		if causes == nil {
+			causes = append([]ErrorInfo(nil), info.Causes...)
+		}
+		causes[i] = rc
+	}
+	if causes != nil {
+		info.Causes, changed = causes, true
+	}
+
+	return info, changed
+}
+
+// types known to have or not have fields tagged `slog:"redact"`, reflect.Type -> bool
+var redactTagTypes sync.Map
+
+// hasRedactTag reports whether values of t contain struct fields tagged `slog:"redact"`
+func hasRedactTag(t reflect.Type) bool {
+	if v, ok := redactTagTypes.Load(t); ok {
+		return v.(bool)
+	}
+	has := typeHasRedactTag(t, map[reflect.Type]bool{})
+	redactTagTypes.Store(t, has)
+	return has
+}
+
+func typeHasRedactTag(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		return typeHasRedactTag(t.Elem(), seen)
+	case reflect.Map:
+		return typeHasRedactTag(t.Elem(), seen)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if !sf.IsExported() {
+				continue
+			}
+			if sf.Tag.Get("slog") == "redact" || typeHasRedactTag(sf.Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// redactTagged returns v with struct fields tagged `slog:"redact"` masked. Structs
+// containing tagged fields are converted to maps keyed by their json names.
+func redactTagged(v interface{}) interface{} {
+	if v == nil || !hasRedactTag(reflect.TypeOf(v)) {
+		return v
+	}
+	out, _ := (*redactor)(nil).redactReflect(reflect.ValueOf(v), 0)
+	return out
+}
+
+// maxRedactDepth bounds how deep redactReflect follows pointers, e.g. in cyclic values
+const maxRedactDepth = 32
+
+// redactReflect returns rv with struct fields tagged `slog:"redact"` masked, and
+// whether anything changed. A non-nil r also masks the struct fields and map
+// entries whose names match its keys and the strings matching its value patterns.
+// Structs and maps that change are converted to maps keyed by their json names.
+func (r *redactor) redactReflect(rv reflect.Value, depth int) (interface{}, bool) {
+	if !rv.IsValid() {
+		return nil, false
+	}
+	if depth > maxRedactDepth || (r == nil && !hasRedactTag(rv.Type())) {
+		return rv.Interface(), false
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		if r != nil {
+			if s := r.redactString(rv.String()); s != rv.String() {
+				return s, true
+			}
+		}
+
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return rv.Interface(), false
+		}
+		if err, ok := rv.Interface().(error); ok && r != nil {
+			return r.redactValue(err)
+		}
+		if v, ok := r.redactReflect(rv.Elem(), depth+1); ok {
+			return v, true
+		}
+
+	case reflect.Slice, reflect.Array:
+		if rv.Type().Elem().Kind() <= reflect.Complex128 {
+			// bools and numbers, e.g. []byte
+			break
+		}
+		var out []interface{}
+		for i := 0; i < rv.Len(); i++ {
+			v, ok := r.redactReflect(rv.Index(i), depth+1)
+			if !ok {
+				continue
+			}
+			if out == nil {
+				out = make([]interface{}, rv.Len())
+				for j := range out {
+					out[j] = rv.Index(j).Interface()
+				}
+			}
+			out[i] = v
+		}
+		if out != nil {
+			return out, true
+		}
+
+	case reflect.Map:
+		out := make(map[string]interface{}, rv.Len())
+		changed := false
+		iter := rv.MapRange()
+		for iter.Next() {
+			k := fmt.Sprint(iter.Key().Interface())
+			if r != nil && r.matchKey(k) {
+				out[k], changed = r.mask, true
+				continue
+			}
+			v, ok := r.redactReflect(iter.Value(), depth+1)
+			out[k], changed = v, changed || ok
+		}
+		if changed {
+			return out, true
+		}
+
+	case reflect.Struct:
+		if err, ok := rv.Interface().(error); ok && r != nil {
+			return r.redactValue(err)
+		}
+		out := make(map[string]interface{}, rv.NumField())
+		if r.redactStruct(rv, out, depth) {
+			return out, true
+		}
+	}
+
+	return rv.Interface(), false
+}
+
+// redactStruct adds the exported fields of rv to out following the json tag
+// conventions for names, "-", omitempty and embedded structs, and reports
+// whether any of them were masked
+func (r *redactor) redactStruct(rv reflect.Value, out map[string]interface{}, depth int) bool {
+	changed := false
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+
+		fv := rv.Field(i)
+		if strings.Contains(opts, "omitempty") && fv.IsZero() {
+			continue
+		}
+
+		if sf.Anonymous && name == "" && fv.Kind() == reflect.Struct {
+			changed = r.redactStruct(fv, out, depth+1) || changed
+			continue
+		}
+
+		if name == "" {
+			name = sf.Name
+		}
+
+		if sf.Tag.Get("slog") == "redact" || (r != nil && r.matchKey(name)) {
+			out[name], changed = r.maskString(), true
+			continue
+		}
+		v, ok := r.redactReflect(fv, depth+1)
+		out[name], changed = v, changed || ok
+	}
+	return changed
+}
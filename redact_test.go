@@ -0,0 +1,105 @@
+package slog
+
+import (
+	stdslog "log/slog"
+	"strings"
+	"testing"
+)
+
+// lastLineWriter keeps the encoding of the last log written to it
+type lastLineWriter struct {
+	format LogFormat
+	line   string
+}
+
+func (w *lastLineWriter) Level() LogLevel { return DebugLevel }
+func (w *lastLineWriter) Close()          {}
+
+func (w *lastLineWriter) Write(l *Log) error {
+	b, err := l.Encode(w.format)
+	w.line = string(b)
+	return err
+}
+
+func newRedactLogger(t *testing.T, format LogFormat) (*SLogger, *lastLineWriter) {
+	t.Helper()
+	w := &lastLineWriter{format: format}
+	l, err := NewLogger(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = l.SetRedaction(&RedactOptions{
+		Keys:          []string{"password", "authorization"},
+		ValuePatterns: []string{`secret-\w+`},
+		Mask:          "***",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l, w
+}
+
+type fieldsError struct {
+	msg    string
+	fields map[string]interface{}
+	err    error
+}
+
+func (e *fieldsError) Error() string                     { return e.msg }
+func (e *fieldsError) Unwrap() error                     { return e.err }
+func (e *fieldsError) LogFields() map[string]interface{} { return e.fields }
+
+func TestRedactErrorKeepsInfo(t *testing.T) {
+	l, w := newRedactLogger(t, FormatJson)
+
+	cause := &fieldsError{msg: "dial secret-host", fields: map[string]interface{}{"password": "hunter2"}}
+	l.Info("x", Err(&fieldsError{msg: "login failed", fields: map[string]interface{}{"user": "bob"}, err: cause}))
+
+	want := `"error":{"msg":"login failed","type":"*slog.fieldsError","fields":{"user":"bob"},` +
+		`"causes":[{"msg":"dial ***","type":"*slog.fieldsError","fields":{"password":"***"}}]}`
+	if !strings.Contains(w.line, want) {
+		t.Errorf("json = %s, want %s", w.line, want)
+	}
+	if strings.Contains(w.line, "hunter2") || strings.Contains(w.line, "secret-host") {
+		t.Errorf("json = %s, want cause message and fields redacted", w.line)
+	}
+}
+
+func TestRedactErrorText(t *testing.T) {
+	l, w := newRedactLogger(t, FormatText)
+
+	l.Info("x", "err", &fieldsError{msg: "token secret-abc", fields: map[string]interface{}{"password": "hunter2"}})
+
+	want := `err="token ***" err.type=*slog.fieldsError err.password=***`
+	if !strings.Contains(w.line, want) {
+		t.Errorf("text = %s, want %s", w.line, want)
+	}
+}
+
+type loginRequest struct {
+	User     string `json:"user"`
+	Password string `json:"password"`
+	Note     string `json:"note"`
+	Token    string `json:"token" slog:"redact"`
+}
+
+func TestRedactStructs(t *testing.T) {
+	l, w := newRedactLogger(t, FormatJson)
+
+	l.Info("x", "req", &loginRequest{User: "bob", Password: "hunter2", Note: "secret-abc", Token: "t"})
+
+	want := `"req":{"note":"***","password":"***","token":"***","user":"bob"}`
+	if !strings.Contains(w.line, want) {
+		t.Errorf("json = %s, want %s", w.line, want)
+	}
+}
+
+func TestRedactDottedKeys(t *testing.T) {
+	l, w := newRedactLogger(t, FormatJson)
+
+	stdslog.New(NewHandler(l)).Info("x", stdslog.Group("headers", stdslog.String("Authorization", "Bearer abc")))
+
+	if !strings.Contains(w.line, `"headers.Authorization":"***"`) {
+		t.Errorf("json = %s, want headers.Authorization redacted", w.line)
+	}
+}
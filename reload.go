@@ -0,0 +1,246 @@
+package slog
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+	"time"
+)
+
+type WatchOptions struct {
+	// PollInterval is how often the config file is checked for changes, 2s if
+	// zero. A negative interval disables polling, leaving only SIGHUP.
+	PollInterval time.Duration
+}
+
+// Reloader keeps a logger in sync with its config file, see WatchConfig.
+type Reloader struct {
+	logger *SLogger
+	path   string
+
+	// built is the config the logger options were set from
+	built *Config
+
+	mu      sync.Mutex
+	current []reloadWriter
+	stat    os.FileInfo
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// reloadWriter is a writer built by the Reloader and the config it was built from
+type reloadWriter struct {
+	cfg WriterConfig
+	w   Writer
+}
+
+// WatchConfig loads the config at path, builds a logger from it and reloads the
+// config whenever the process receives SIGHUP or the file changes.
+//
+// A reload rebuilds only the writers whose config changed: writers that were
+// removed or changed are detached, then closed once the logs being written to
+// them finish, which drains the queues of file and HTTP writers. Module levels
+// are reloaded too. The other logger options (caller, stack traces, flush
+// timeout, dedup, sampling and redaction) only take effect when the logger is
+// built; changing them is reported as a warning. An invalid config is reported
+// as an error and the current one is kept.
+func WatchConfig(path string, opt *WatchOptions) (*Reloader, error) {
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	stat, _ := os.Stat(path)
+
+	wcs := cfg.writerConfigs()
+	writers, err := newWriters(wcs)
+	if err != nil {
+		return nil, err
+	}
+	l, err := NewLogger(writers...)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.apply(l); err != nil {
+		l.Close()
+		return nil, err
+	}
+
+	r := &Reloader{
+		logger:  l,
+		path:    path,
+		built:   cfg,
+		current: make([]reloadWriter, len(wcs)),
+		stat:    stat,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	for i := range wcs {
+		r.current[i] = reloadWriter{cfg: wcs[i], w: writers[i]}
+	}
+
+	interval := 2 * time.Second
+	if opt != nil && opt.PollInterval != 0 {
+		interval = opt.PollInterval
+	}
+	go r.run(interval)
+	return r, nil
+}
+
+// Logger returns the logger kept in sync with the config file
+func (r *Reloader) Logger() *SLogger {
+	return r.logger
+}
+
+func (r *Reloader) run(interval time.Duration) {
+	defer close(r.done)
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var poll <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+
+	for {
+		select {
+		case <-r.stop:
+			return
+
+		case <-hup:
+			r.reload()
+
+		case <-poll:
+			if r.changed() {
+				r.reload()
+			}
+		}
+	}
+}
+
+// changed reports whether the config file was modified since it was last loaded
+func (r *Reloader) changed() bool {
+	stat, err := os.Stat(r.path)
+	if err != nil {
+		// e.g. mid-way through an editor replacing the file, try again next time
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stat == nil || !stat.ModTime().Equal(r.stat.ModTime()) || stat.Size() != r.stat.Size()
+}
+
+func (r *Reloader) reload() {
+	if err := r.Reload(); err != nil {
+		r.logger.Error("failed to reload logger config", "path", r.path, Err(err))
+		return
+	}
+	r.logger.Info("reloaded logger config", "path", r.path)
+}
+
+// Reload loads the config file and applies it now. On error the current config is kept.
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// recorded first, so an invalid file is not retried until it changes again
+	r.stat, _ = os.Stat(r.path)
+
+	cfg, err := LoadConfig(r.path)
+	if err != nil {
+		return err
+	}
+
+	wcs := cfg.writerConfigs()
+	next := make([]reloadWriter, len(wcs))
+	unused := append([]reloadWriter(nil), r.current...)
+	var added []Writer
+
+	for i, wc := range wcs {
+		// keep the existing writer if its config did not change
+		for j, rw := range unused {
+			if rw.cfg == wc {
+				next[i] = rw
+				unused = append(unused[:j], unused[j+1:]...)
+				break
+			}
+		}
+		if next[i].w != nil {
+			continue
+		}
+
+		w, err := wc.newWriter()
+		if err != nil {
+			for _, w := range added {
+				w.Close()
+			}
+			return &ConfigError{Field: fmt.Sprintf("writers[%d]", i), Err: err}
+		}
+		next[i] = reloadWriter{cfg: wc, w: w}
+		added = append(added, w)
+	}
+
+	removed := make(map[Writer]bool, len(unused))
+	for _, rw := range unused {
+		removed[rw.w] = true
+	}
+
+	// writers attached by other means are kept; update returns once no log is
+	// still being written to the removed writers
+	r.logger.writers.update(func(old []Writer) []Writer {
+		writers := make([]Writer, 0, len(old)+len(added))
+		for _, w := range old {
+			if !removed[w] {
+				writers = append(writers, w)
+			}
+		}
+		return append(writers, added...)
+	})
+
+	var wg sync.WaitGroup
+	for w := range removed {
+		wg.Add(1)
+		go func(w Writer) {
+			defer wg.Done()
+			w.Close()
+		}(w)
+	}
+	wg.Wait()
+
+	if err := SetModuleLevels(cfg.Modules); err != nil {
+		return &ConfigError{Field: "modules", Err: err}
+	}
+
+	if !r.built.sameOptions(cfg) {
+		r.logger.Warn("logger options other than writers and modules need a restart to change", "path", r.path)
+	}
+
+	r.current = next
+	return nil
+}
+
+// sameOptions reports whether cfg and other differ only in writers and module levels
+func (cfg *Config) sameOptions(other *Config) bool {
+	a, b := *cfg, *other
+	a.Level, b.Level = "", ""
+	a.Modules, b.Modules = "", ""
+	a.Writers, b.Writers = nil, nil
+	return reflect.DeepEqual(a, b)
+}
+
+// Close stops watching the config file. The logger is left open.
+func (r *Reloader) Close() {
+	r.closeOnce.Do(func() {
+		close(r.stop)
+	})
+	<-r.done
+}
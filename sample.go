@@ -0,0 +1,165 @@
+package slog
+
+import (
+	"sync"
+	"time"
+)
+
+type SamplingOptions struct {
+	// Interval over which logs are counted, 1s if zero
+	Interval time.Duration
+	// First logs with the same level and message are written each interval, 100 if zero
+	First int
+	// Thereafter every Mth log with the same level and message is written;
+	// 0 drops all of them until the next interval
+	Thereafter int
+}
+
+type sampleKey struct {
+	lvl LogLevel
+	msg string
+}
+
+// sampler counts logs per level and message, and reports what it dropped as a
+// Stat log once per interval
+type sampler struct {
+	opt SamplingOptions
+
+	// owner is the logger SetSampling created the sampler on. Children created
+	// with With or Named share it, but only the owner closes it.
+	owner *SLogger
+
+	mu      sync.Mutex
+	counts  map[sampleKey]int
+	dropped int
+	closed  bool
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// SetSampling caps repeated logs: within each interval, the first N logs with the
+// same level and message are written, then only every Mth. Fatal and Panic logs
+// are never sampled. A nil opt disables sampling.
+//
+// Children created with With or Named share the sampling of their parent; calling
+// SetSampling on a child replaces it for that child only.
+func (s *SLogger) SetSampling(opt *SamplingOptions) {
+	if opt == nil {
+		s.swapSampler(nil)
+		return
+	}
+
+	o := *opt
+	if o.Interval <= 0 {
+		o.Interval = time.Second
+	}
+	if o.First <= 0 {
+		o.First = 100
+	}
+
+	sm := &sampler{
+		opt:    o,
+		owner:  s,
+		counts: make(map[sampleKey]int),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go sm.run(s)
+	s.swapSampler(sm)
+}
+
+// swapSampler installs sm, closing the sampler it replaces if s created it
+func (s *SLogger) swapSampler(sm *sampler) {
+	if old := s.sampler.Swap(sm); old != nil && old.owner == s {
+		old.close()
+	}
+}
+
+// sampled reports whether the sampling of s, if any, lets a log through
+func (s *SLogger) sampled(lvl LogLevel, msg string) bool {
+	sm := s.sampler.Load()
+	return sm == nil || sm.allow(lvl, msg)
+}
+
+func SetSampling(opt *SamplingOptions) {
+	if Slog == nil {
+		return
+	}
+	Slog.SetSampling(opt)
+}
+
+func (sm *sampler) allow(lvl LogLevel, msg string) bool {
+	if lvl.rank() >= FatalLevel {
+		return true
+	}
+
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	// children created with With may outlive a sampler replaced on their parent
+	if sm.closed {
+		return true
+	}
+
+	key := sampleKey{lvl: lvl, msg: msg}
+	n := sm.counts[key] + 1
+	sm.counts[key] = n
+
+	if n <= sm.opt.First {
+		return true
+	}
+	if sm.opt.Thereafter > 0 && (n-sm.opt.First)%sm.opt.Thereafter == 0 {
+		return true
+	}
+
+	sm.dropped++
+	return false
+}
+
+func (sm *sampler) run(s *SLogger) {
+	defer close(sm.done)
+
+	ticker := time.NewTicker(sm.opt.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-sm.stop:
+			sm.report(s)
+			return
+		case <-ticker.C:
+			sm.report(s)
+		}
+	}
+}
+
+// report resets the counts and writes a Stat log if anything was dropped
+func (sm *sampler) report(s *SLogger) {
+	sm.mu.Lock()
+	dropped := sm.dropped
+	sm.dropped = 0
+	clear(sm.counts)
+	sm.mu.Unlock()
+
+	if dropped == 0 {
+		return
+	}
+	s.writeLogPCs(nil, time.Now(), StatLevel, "sampling dropped logs", []Field{
+		Int("dropped", dropped),
+		Dur("interval", sm.opt.Interval),
+	})
+}
+
+// close stops the sampler after reporting any remaining drops
+func (sm *sampler) close() {
+	sm.closeOnce.Do(func() {
+		close(sm.stop)
+		<-sm.done
+
+		sm.mu.Lock()
+		sm.closed = true
+		sm.mu.Unlock()
+	})
+}
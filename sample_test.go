@@ -0,0 +1,44 @@
+package slog
+
+import (
+	"testing"
+	"time"
+)
+
+// closeCountWriter counts the logs written to it and how often it was closed
+type closeCountWriter struct {
+	writes int
+	closes int
+}
+
+func (w *closeCountWriter) Level() LogLevel    { return DebugLevel }
+func (w *closeCountWriter) Write(_ *Log) error { w.writes++; return nil }
+func (w *closeCountWriter) Close()             { w.closes++ }
+
+func TestChildDoesNotCloseParentSampling(t *testing.T) {
+	w := &closeCountWriter{}
+	parent, err := NewLogger(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent.SetSampling(&SamplingOptions{Interval: time.Hour, First: 1})
+	defer parent.Close()
+
+	child := parent.With("k", "v")
+	child.SetSampling(nil)
+	parent.Named("db").Close()
+
+	for i := 0; i < 3; i++ {
+		parent.Info("x")
+	}
+	if w.writes != 1 {
+		t.Errorf("parent wrote %d logs, want 1 with its sampling still active", w.writes)
+	}
+
+	for i := 0; i < 3; i++ {
+		child.Info("y")
+	}
+	if w.writes != 4 {
+		t.Errorf("wrote %d logs, want the child to stop sampling", w.writes)
+	}
+}
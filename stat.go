@@ -0,0 +1,79 @@
+package slog
+
+import "sync/atomic"
+
+// StatMode selects whether a writer receives Stat logs, independently of its level.
+type StatMode int
+
+const (
+	// StatsByLevel writes stats when the stat rank (see SetStatRank) is at or above the writer's level
+	StatsByLevel StatMode = iota
+	// StatsAlways writes stats regardless of the writer's level
+	StatsAlways
+	// StatsNever never writes stats
+	StatsNever
+	// StatsOnly writes stats and nothing else
+	StatsOnly
+)
+
+// StatFilter is implemented by writers that choose which Stat logs they receive.
+// Writers without it use StatsByLevel.
+type StatFilter interface {
+	StatMode() StatMode
+}
+
+// statRank is the level stats are compared as against writer levels
+var statRank atomic.Int64
+
+func init() {
+	statRank.Store(int64(InfoLevel))
+}
+
+// SetStatRank places stats in the level ordering: writers using StatsByLevel
+// receive stats if they would receive a log at l. It defaults to InfoLevel.
+func SetStatRank(l LogLevel) {
+	statRank.Store(int64(l))
+	levelsChanged()
+}
+
+// rank returns the level l is ordered as, which differs from l only for StatLevel
+func (l LogLevel) rank() LogLevel {
+	if l == StatLevel {
+		return LogLevel(statRank.Load())
+	}
+	return l
+}
+
+func statModeOf(w Writer) StatMode {
+	if sf, ok := w.(StatFilter); ok {
+		return sf.StatMode()
+	}
+	return StatsByLevel
+}
+
+// writerAcceptsLog reports whether w should write l, which is also the case below
+// the level of w if the module level of l's logger admitted it (see SetModuleLevels)
+func writerAcceptsLog(w Writer, l *Log) bool {
+	if l.moduleOpened && statModeOf(w) != StatsOnly {
+		return true
+	}
+	return writerAccepts(w, l.Level)
+}
+
+// writerAccepts reports whether w should write a log at lvl
+func writerAccepts(w Writer, lvl LogLevel) bool {
+	mode := statModeOf(w)
+
+	if lvl != StatLevel {
+		return mode != StatsOnly && lvl >= w.Level()
+	}
+
+	switch mode {
+	case StatsAlways, StatsOnly:
+		return true
+	case StatsNever:
+		return false
+	default:
+		return lvl.rank() >= w.Level()
+	}
+}
@@ -0,0 +1,50 @@
+package slog
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStatIsNotAWriterLevel(t *testing.T) {
+	cfg := &Config{Level: "stat", Writers: []WriterConfig{{Type: "stdout", Level: "stat"}}}
+	err := cfg.Validate()
+	for _, field := range []string{"level", "writers[0].level"} {
+		if !hasConfigError(err, field) {
+			t.Errorf("Validate() = %v, want an error for %s", err, field)
+		}
+	}
+
+	t.Setenv(EnvLevel, "stat")
+	if _, err := NewLoggerFromEnv(); err == nil {
+		t.Errorf("NewLoggerFromEnv() with %s=stat succeeded, want an error", EnvLevel)
+	}
+
+	l, err := NewLogger(&captureWriter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	AdminHandler(l).ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/levels?level=stat", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("PUT /levels?level=stat = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+// hasConfigError reports whether err contains a *ConfigError for field
+func hasConfigError(err error, field string) bool {
+	var errs []error
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = joined.Unwrap()
+	} else if err != nil {
+		errs = []error{err}
+	}
+	for _, e := range errs {
+		var ce *ConfigError
+		if errors.As(e, &ce) && ce.Field == field {
+			return true
+		}
+	}
+	return false
+}
@@ -1,8 +1,13 @@
 package slog
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
+	"fmt"
+	"math"
 	"regexp"
+	"sync"
+	"sync/atomic"
 )
 
 type LogFormat string
@@ -25,40 +30,59 @@ const (
 	ErrorLevelString LogLevelString = "error"
 	FatalLevelString LogLevelString = "fatal"
 	PanicLevelString LogLevelString = "panic"
+	StatLevelString  LogLevelString = "stat"
 )
 
+// The built-in levels are spaced apart so custom levels can be registered
+// between them (see RegisterLevel).
 const (
-	DebugLevel LogLevel = iota + 1
+	DebugLevel LogLevel = 10 * (iota + 1)
 	InfoLevel
 	WarnLevel
 	ErrorLevel
 	FatalLevel
 	PanicLevel
+	// StatLevel is for statistics and metrics. Its place in the ordering is set
+	// with SetStatRank, and writers can opt in or out of it with a StatMode.
+	StatLevel
 )
 
+// ToLogLevel returns the registered level named s, or InfoLevel if there is none
 func ToLogLevel(s string) LogLevel {
-	switch s {
-	case string(DebugLevelString):
-		return DebugLevel
-
-	case string(InfoLevelString):
-		return InfoLevel
-
-	case string(WarnLevelString):
-		return WarnLevel
+	if info, ok := LookupLevel(s); ok {
+		return info.Level
+	}
+	return InfoLevel
+}
 
-	case string(ErrorLevelString):
-		return ErrorLevel
+// ToLogLevelString returns the name of l, or "info" if l is not registered
+func ToLogLevelString(l LogLevel) LogLevelString {
+	if info, ok := levels.Load().byLevel[l]; ok {
+		return LogLevelString(info.Name)
+	}
+	return InfoLevelString
+}
 
-	case string(FatalLevelString):
-		return FatalLevel
+func IsValidLogLevel(s string) bool {
+	_, ok := LookupLevel(s)
+	return ok
+}
 
-	case string(PanicLevelString):
-		return PanicLevel
+// errStatWriterLevel is returned for "stat" given as the level of a writer.
+// StatLevel is above PanicLevel, so such a writer would drop every other log;
+// which stats a writer gets is set by its StatMode and SetStatRank instead.
+var errStatWriterLevel = errors.New(`"stat" is not a writer level, use the writer's stats mode to select stats`)
 
-	default:
-		return InfoLevel
+// checkWriterLevel returns an error if s does not name a level a writer can be set to
+func checkWriterLevel(s string) error {
+	info, ok := LookupLevel(s)
+	if !ok {
+		return fmt.Errorf("invalid level %q", s)
+	}
+	if info.Level == StatLevel {
+		return errStatWriterLevel
 	}
+	return nil
 }
 
 func ToLogFormat(s string) LogFormat {
@@ -89,38 +113,12 @@ func IsValidLogFormat(s string) bool {
 
 var ansiRegex = regexp.MustCompile(`\x1b\[[0-9;]*m`)
 
-func removeAnsiBytes(b []byte) []byte {
-	return ansiRegex.ReplaceAll(b, []byte{})
-}
-
 func RemoveAnsiString(s string) string {
 	return ansiRegex.ReplaceAllString(s, "")
 }
 
 func encodeLog(l *Log, f LogFormat) ([]byte, error) {
-	var b []byte
-	var err error
-
-	switch f {
-
-	case FormatJson:
-		b, err = json.Marshal(l)
-		if err != nil {
-			return b, err
-		}
-		b = append(b, '\n')
-
-	case FormatText:
-		b = removeAnsiBytes([]byte(l.Str))
-
-	case FormatAnsi:
-		b = []byte(l.Str)
-
-	default:
-		b = removeAnsiBytes([]byte(l.Str))
-	}
-
-	return b, nil
+	return l.Encode(f)
 }
 
 func EncodeLogToInterface(l Log, f LogFormat) interface{} {
@@ -130,15 +128,225 @@ func EncodeLogToInterface(l Log, f LogFormat) interface{} {
 		return l
 
 	case FormatAnsi:
-		return l.Str
+		return l.encodeString(FormatAnsi)
 
 	default:
-		return RemoveAnsiString(l.Str)
+		return l.encodeString(FormatText)
 	}
 }
 
+// Writer receives every log at or above its level, and Stat logs as selected by
+// its StatMode (see StatFilter). The *Log passed to Write is reused after Write
+// returns, so it must not be retained; keep a Copy instead. Write must not add,
+// remove or replace the writers of the logger calling it.
 type Writer interface {
 	Level() LogLevel
 	Write(*Log) error
 	Close()
 }
+
+// Flusher is implemented by writers that queue entries asynchronously.
+// Flush blocks until every entry queued before the call has been written, or ctx is done.
+type Flusher interface {
+	Flush(ctx context.Context) error
+}
+
+// LevelSetter is implemented by writers whose level can be changed at runtime.
+// Custom implementations should change levels through SLogger.SetLevel, or call
+// LevelsChanged afterwards, so loggers notice the new level.
+type LevelSetter interface {
+	SetLevel(LogLevel)
+}
+
+// LevelsChanged tells loggers to recompute the lowest level of their writers.
+// The built-in writers call it from SetLevel.
+func LevelsChanged() {
+	levelsChanged()
+}
+
+// levelGen is bumped whenever any writer's level or any writer set changes,
+// invalidating the cached minimum level of every writerSet
+var levelGen atomic.Uint64
+
+func levelsChanged() {
+	levelGen.Add(1)
+}
+
+// writerSet is a copy-on-write list of writers shared by a logger and its children.
+// The slice is never modified in place, so a snapshot can be ranged over without holding the lock.
+type writerSet struct {
+	mu  sync.RWMutex
+	cur *writerSnapshot
+
+	// lowest level of any writer and whether any writer takes stats,
+	// valid while minGen == levelGen+1
+	min    atomic.Int64
+	stats  atomic.Bool
+	minGen atomic.Uint64
+}
+
+// writerSnapshot is one version of a writerSet. inUse counts the logs being
+// written to it, plus one while it is current, so writers that are removed can
+// be closed once it drops to zero and drained is closed. prev links the snapshots
+// it replaced that are not drained yet.
+type writerSnapshot struct {
+	writers []Writer
+	inUse   atomic.Int64
+	drained chan struct{}
+	prev    atomic.Pointer[writerSnapshot]
+}
+
+func newWriterSet(writers ...Writer) *writerSet {
+	return &writerSet{cur: newWriterSnapshot(writers)}
+}
+
+func newWriterSnapshot(writers []Writer) *writerSnapshot {
+	sn := &writerSnapshot{writers: writers, drained: make(chan struct{})}
+	sn.inUse.Store(1)
+	return sn
+}
+
+func (ws *writerSet) list() []Writer {
+	if ws == nil {
+		return nil
+	}
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+	return ws.cur.writers
+}
+
+// acquire returns the current snapshot for writing a log, the caller must release it
+func (ws *writerSet) acquire() *writerSnapshot {
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+	ws.cur.inUse.Add(1)
+	ws.cur.dropDrained()
+	return ws.cur
+}
+
+func (sn *writerSnapshot) release() {
+	if sn.inUse.Add(-1) == 0 {
+		close(sn.drained)
+	}
+}
+
+// dropDrained unlinks the snapshots before sn that no log is being written to
+// any more. Only drained snapshots are unlinked, so it may race with itself.
+func (sn *writerSnapshot) dropDrained() {
+	for p := sn; p != nil; {
+		q := p.prev.Load()
+		if q != nil && q.inUse.Load() == 0 {
+			p.prev.CompareAndSwap(q, q.prev.Load())
+			continue
+		}
+		p = q
+	}
+}
+
+// enabled reports whether any writer accepts a log at lvl and the module level
+// of m permits it, or the module level of m opens lvl up (see SetModuleLevels).
+// The lowest writer level is cached and only recomputed after a level or writer
+// change. The module is checked here, and last, so the common case of a level
+// below every writer costs a single call.
+func (ws *writerSet) enabled(lvl LogLevel, m *module) bool {
+	if ws == nil {
+		return false
+	}
+
+	gen := levelGen.Load()
+	if ws.minGen.Load() != gen+1 {
+		ws.refresh(gen)
+	}
+
+	if lvl == StatLevel {
+		if !ws.stats.Load() {
+			return false
+		}
+	} else if lvl < LogLevel(ws.min.Load()) {
+		return m != nil && m.opens(lvl)
+	}
+	return m.allows(lvl)
+}
+
+func (ws *writerSet) refresh(gen uint64) {
+	min := LogLevel(math.MaxInt)
+	stats := false
+	for _, w := range ws.list() {
+		if w == nil {
+			continue
+		}
+		if statModeOf(w) != StatsOnly && w.Level() < min {
+			min = w.Level()
+		}
+		if writerAccepts(w, StatLevel) {
+			stats = true
+		}
+	}
+
+	ws.min.Store(int64(min))
+	ws.stats.Store(stats)
+	ws.minGen.Store(gen + 1)
+}
+
+// add appends w without waiting, as no writer is dropped. The snapshot it
+// replaces is chained to the new one until drained, so a later update still
+// waits for it.
+func (ws *writerSet) add(w Writer) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	defer levelsChanged()
+
+	writers := make([]Writer, 0, len(ws.cur.writers)+1)
+	writers = append(writers, ws.cur.writers...)
+	ws.swap(append(writers, w)...).dropDrained()
+}
+
+func (ws *writerSet) remove(w Writer) bool {
+	writers := make([]Writer, 0, len(ws.list()))
+	removed := false
+	ws.update(func(old []Writer) []Writer {
+		for _, x := range old {
+			if x == w {
+				removed = true
+				continue
+			}
+			writers = append(writers, x)
+		}
+		return writers
+	})
+	return removed
+}
+
+func (ws *writerSet) replace(writers []Writer) []Writer {
+	var old []Writer
+	ws.update(func(cur []Writer) []Writer {
+		old = cur
+		return writers
+	})
+	return old
+}
+
+// update swaps in the writers returned by fn, then waits until no log is still
+// being written to any previous snapshot, including those replaced by add, so
+// writers that were dropped can be closed.
+func (ws *writerSet) update(fn func(old []Writer) []Writer) {
+	ws.mu.Lock()
+	next := ws.swap(fn(ws.cur.writers)...)
+	ws.mu.Unlock()
+	levelsChanged()
+
+	// snapshots stop being acquired once replaced, so each count only drops
+	for sn := next.prev.Load(); sn != nil; sn = sn.prev.Load() {
+		<-sn.drained
+	}
+	next.prev.Store(nil)
+}
+
+// swap installs a snapshot of writers chained to the current one, ws.mu must be held
+func (ws *writerSet) swap(writers ...Writer) *writerSnapshot {
+	next := newWriterSnapshot(writers)
+	next.prev.Store(ws.cur)
+	ws.cur.release()
+	ws.cur = next
+	return next
+}
@@ -0,0 +1,95 @@
+package slog
+
+import (
+	"testing"
+	"time"
+)
+
+// blockingWriter blocks every Write until unblock is closed
+type blockingWriter struct {
+	entered chan struct{}
+	unblock chan struct{}
+}
+
+func (w *blockingWriter) Level() LogLevel { return DebugLevel }
+func (w *blockingWriter) Close()          {}
+
+func (w *blockingWriter) Write(_ *Log) error {
+	w.entered <- struct{}{}
+	<-w.unblock
+	return nil
+}
+
+func TestRemoveWriterWaitsAfterAdd(t *testing.T) {
+	a := &blockingWriter{entered: make(chan struct{}, 1), unblock: make(chan struct{})}
+	l, err := NewLogger(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go l.Info("x")
+	<-a.entered
+
+	l.AddWriter(&recordWriter{})
+
+	removed := make(chan struct{})
+	go func() {
+		l.RemoveWriter(a)
+		close(removed)
+	}()
+
+	select {
+	case <-removed:
+		t.Fatal("RemoveWriter returned while a log was still being written to the writer")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(a.unblock)
+	select {
+	case <-removed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("RemoveWriter did not return after the write finished")
+	}
+}
+
+// chainLen acquires the current snapshot of ws, as a log does, and returns how
+// many replaced snapshots it still links to
+func chainLen(ws *writerSet) int {
+	cur := ws.acquire()
+	defer cur.release()
+
+	n := 0
+	for sn := cur.prev.Load(); sn != nil; sn = sn.prev.Load() {
+		n++
+	}
+	return n
+}
+
+func TestAddWriterDropsDrainedSnapshots(t *testing.T) {
+	a := &blockingWriter{entered: make(chan struct{}, 1), unblock: make(chan struct{})}
+	l, err := NewLogger(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	written := make(chan struct{})
+	go func() {
+		l.Info("x")
+		close(written)
+	}()
+	<-a.entered
+
+	for i := 0; i < 10; i++ {
+		l.AddWriter(&recordWriter{})
+	}
+	// only the snapshot the log is being written to is kept
+	if n := chainLen(l.writers); n != 1 {
+		t.Errorf("after adding writers during a log, %d snapshots are linked, want 1", n)
+	}
+
+	close(a.unblock)
+	<-written
+	if n := chainLen(l.writers); n != 0 {
+		t.Errorf("after the log was written, %d snapshots are linked, want 0", n)
+	}
+}
@@ -4,12 +4,14 @@ import "sync"
 
 type ToChanWriterOptions struct {
 	Level LogLevel
+	Stats StatMode
 	Ch    chan *Log
 }
 
 type toChanWriter struct {
 	mu    sync.RWMutex
 	level LogLevel
+	stats StatMode
 	ch    chan *Log
 }
 
@@ -19,6 +21,17 @@ func (w *toChanWriter) Level() LogLevel {
 	return w.level
 }
 
+func (w *toChanWriter) SetLevel(l LogLevel) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	defer levelsChanged()
+	w.level = l
+}
+
+func (w *toChanWriter) StatMode() StatMode {
+	return w.stats
+}
+
 func WithToChanWriter(opt *ToChanWriterOptions) *toChanWriter {
 	if opt == nil {
 		// will be skipped
@@ -27,6 +40,7 @@ func WithToChanWriter(opt *ToChanWriterOptions) *toChanWriter {
 
 	return &toChanWriter{
 		level: opt.Level,
+		stats: opt.Stats,
 		ch:    opt.Ch,
 	}
 }
@@ -45,11 +59,14 @@ func (w *toChanWriter) Write(l *Log) error {
 		return nil
 	}
 
-	if l.Level < w.Level() {
+	if !writerAcceptsLog(w, l) {
 		return nil
 	}
 
 	log := l.Copy()
+	if b, err := l.Encode(FormatAnsi); err == nil {
+		log.Str = string(b)
+	}
 	w.write(&log)
 	return nil
 }
@@ -58,4 +75,8 @@ func (w *toChanWriter) Close() {
 	close(w.ch)
 }
 
-var _ Writer = &toChanWriter{}
+var (
+	_ Writer      = &toChanWriter{}
+	_ LevelSetter = &toChanWriter{}
+	_ StatFilter  = &toChanWriter{}
+)
@@ -1,6 +1,7 @@
 package slog
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"os"
@@ -12,12 +13,15 @@ import (
 const writeCheckInterval int = 200
 
 type ToFileWriter struct {
-	opt    ToFileWriterOptions
-	logCh  chan []byte
-	ctx    context.Context
-	cancel context.CancelFunc
-	wg     sync.WaitGroup
-	f      *os.File
+	mu      sync.RWMutex
+	opt     ToFileWriterOptions
+	logCh   chan *bytes.Buffer
+	flushCh chan chan struct{}
+	stopped chan struct{}
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	f       *os.File
 }
 
 func (w *ToFileWriter) Format() LogFormat {
@@ -25,9 +29,22 @@ func (w *ToFileWriter) Format() LogFormat {
 }
 
 func (w *ToFileWriter) Level() LogLevel {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
 	return w.opt.Level
 }
 
+func (w *ToFileWriter) SetLevel(l LogLevel) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	defer levelsChanged()
+	w.opt.Level = l
+}
+
+func (w *ToFileWriter) StatMode() StatMode {
+	return w.opt.Stats
+}
+
 func (w *ToFileWriter) FileName() string {
 	return w.opt.FileName
 }
@@ -36,6 +53,7 @@ type ToFileWriterOptions struct {
 	FileName   string
 	Format     LogFormat
 	Level      LogLevel
+	Stats      StatMode
 	RotateSize int64 // kB
 }
 
@@ -65,10 +83,12 @@ func WithToFileWriter(opt *ToFileWriterOptions) *ToFileWriter {
 	opt.toDefaultIfEmpty()
 	ctx, cancel := context.WithCancel(context.Background())
 	w := &ToFileWriter{
-		opt:    *opt,
-		logCh:  make(chan []byte, 100),
-		ctx:    ctx,
-		cancel: cancel,
+		opt:     *opt,
+		logCh:   make(chan *bytes.Buffer, 100),
+		flushCh: make(chan chan struct{}),
+		stopped: make(chan struct{}),
+		ctx:     ctx,
+		cancel:  cancel,
 	}
 	w.wg.Add(1)
 	go w.run()
@@ -77,6 +97,7 @@ func WithToFileWriter(opt *ToFileWriterOptions) *ToFileWriter {
 
 func (w *ToFileWriter) run() {
 	defer w.wg.Done()
+	defer close(w.stopped)
 
 	if err := w.openFile(); err != nil {
 		fmt.Printf("ToFileWriter.run(): failed to open file: %v\n", err)
@@ -84,32 +105,42 @@ func (w *ToFileWriter) run() {
 	}
 
 	var writeCounter int = 0
+	write := func(logEntry *bytes.Buffer) {
+		w.writeLog(logEntry.Bytes())
+		putBuffer(logEntry)
+		writeCounter++
+		if writeCounter >= writeCheckInterval {
+			w.rotate()
+			writeCounter = 0
+		}
+	}
+
+	// drain writes everything currently queued
+	drain := func() {
+		for {
+			select {
+			case logEntry := <-w.logCh:
+				write(logEntry)
+			default:
+				return
+			}
+		}
+	}
 
 	for {
 		select {
 		case <-w.ctx.Done():
-			for {
-				select {
-				case logEntry := <-w.logCh:
-					w.writeLog(logEntry)
-					writeCounter++
-					if writeCounter >= writeCheckInterval {
-						w.rotate()
-						writeCounter = 0
-					}
-				default:
-					w.closeFile()
-					return
-				}
-			}
+			drain()
+			w.closeFile()
+			return
+
+		case done := <-w.flushCh:
+			drain()
+			w.syncFile()
+			close(done)
 
 		case logEntry := <-w.logCh:
-			w.writeLog(logEntry)
-			writeCounter++
-			if writeCounter >= writeCheckInterval {
-				w.rotate()
-				writeCounter = 0
-			}
+			write(logEntry)
 		}
 	}
 }
@@ -192,22 +223,57 @@ func (w *ToFileWriter) Write(l *Log) error {
 		return joinError("ToFileWriter.Write(): failed to encode log", err)
 	}
 
+	// the encoding belongs to l, so queue a pooled copy
+	buf := getBuffer()
+	buf.Write(b)
+
 	select {
 	case <-w.ctx.Done():
+		putBuffer(buf)
 		fmt.Printf("ToFileWriter.Write(): failed to write log entry to file: context canceled\n")
-	case w.logCh <- b:
+	case w.logCh <- buf:
 	default:
+		putBuffer(buf)
 		fmt.Printf("ToFileWriter.Write(): failed to write log entry to file: buffer full\n")
 	}
 
 	return nil
 }
 
+// Flush blocks until every entry queued before the call is written and synced to disk,
+// or ctx is done.
+func (w *ToFileWriter) Flush(ctx context.Context) error {
+	done := make(chan struct{})
+	select {
+	case w.flushCh <- done:
+	case <-w.stopped:
+		return nil
+	case <-ctx.Done():
+		return joinError("ToFileWriter.Flush()", ctx.Err())
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return joinError("ToFileWriter.Flush()", ctx.Err())
+	}
+}
+
 func (w *ToFileWriter) Close() {
 	w.cancel()
 	w.wg.Wait()
 }
 
+func (w *ToFileWriter) syncFile() {
+	if w.f == nil {
+		return
+	}
+	if err := w.f.Sync(); err != nil {
+		fmt.Printf("ToFileWriter.syncFile(): failed to sync file: %v\n", err)
+	}
+}
+
 func (w *ToFileWriter) closeFile() {
 	if w.f != nil {
 		w.f.Close()
@@ -215,4 +281,9 @@ func (w *ToFileWriter) closeFile() {
 	}
 }
 
-var _ Writer = &ToFileWriter{}
+var (
+	_ Writer      = &ToFileWriter{}
+	_ LevelSetter = &ToFileWriter{}
+	_ StatFilter  = &ToFileWriter{}
+	_ Flusher     = &ToFileWriter{}
+)
@@ -12,6 +12,7 @@ import (
 
 type ToHttpWriterOptions struct {
 	Level  LogLevel
+	Stats  StatMode
 	Format LogFormat
 	URL    string
 	Method string
@@ -21,16 +22,29 @@ type ToHttpWriterOptions struct {
 type ToHttpWriter struct {
 	mu     sync.RWMutex
 	level  LogLevel
+	stats  StatMode
 	format LogFormat
 	url    string
 	method string
 	apiKey string
 
-	ctx    context.Context
-	cancel context.CancelFunc
-	wg     sync.WaitGroup
-	logCh  chan *Log
-	client *http.Client
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	logCh   chan *bytes.Buffer
+	flushCh chan chan struct{}
+	stopped chan struct{}
+	client  *http.Client
+}
+
+func isValidHttpMethod(m string) bool {
+	switch m {
+	case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete,
+		http.MethodPatch, http.MethodOptions, http.MethodHead:
+		return true
+	default:
+		return false
+	}
 }
 
 func validateToHttpWriterOptions(opt *ToHttpWriterOptions) error {
@@ -47,16 +61,7 @@ func validateToHttpWriterOptions(opt *ToHttpWriterOptions) error {
 	if opt.Method == "" {
 		opt.Method = http.MethodPut
 	}
-	validMethods := map[string]bool{
-		http.MethodGet:     true,
-		http.MethodPost:    true,
-		http.MethodPut:     true,
-		http.MethodDelete:  true,
-		http.MethodPatch:   true,
-		http.MethodOptions: true,
-		http.MethodHead:    true,
-	}
-	if !validMethods[opt.Method] {
+	if !isValidHttpMethod(opt.Method) {
 		return fmt.Errorf("invalid HTTP method: %s", opt.Method)
 	}
 
@@ -79,14 +84,17 @@ func WithToHttpWriter(opt *ToHttpWriterOptions) *ToHttpWriter {
 
 	ctx, cancel := context.WithCancel(context.Background())
 	w := &ToHttpWriter{
-		level:  opt.Level,
-		format: opt.Format,
-		url:    opt.URL,
-		method: opt.Method,
-		apiKey: opt.APIKey,
-		ctx:    ctx,
-		cancel: cancel,
-		logCh:  make(chan *Log, 100),
+		level:   opt.Level,
+		stats:   opt.Stats,
+		format:  opt.Format,
+		url:     opt.URL,
+		method:  opt.Method,
+		apiKey:  opt.APIKey,
+		ctx:     ctx,
+		cancel:  cancel,
+		logCh:   make(chan *bytes.Buffer, 100),
+		flushCh: make(chan chan struct{}),
+		stopped: make(chan struct{}),
 		client: &http.Client{
 			Timeout: 5 * time.Second,
 		},
@@ -103,22 +111,44 @@ func (w *ToHttpWriter) Level() LogLevel {
 	return w.level
 }
 
+func (w *ToHttpWriter) SetLevel(l LogLevel) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	defer levelsChanged()
+	w.level = l
+}
+
+func (w *ToHttpWriter) StatMode() StatMode {
+	return w.stats
+}
+
 func (w *ToHttpWriter) Write(l *Log) error {
 	if l == nil {
 		return nil
 	}
 
-	if l.Level < w.Level() {
+	if !writerAcceptsLog(w, l) {
 		return nil
 	}
 
+	b, err := encodeLog(l, w.format)
+	if err != nil {
+		return joinError("ToHttpWriter.Write(): failed to encode log", err)
+	}
+
+	// the encoding belongs to l, so queue a pooled copy
+	buf := getBuffer()
+	buf.Write(b)
+
 	select {
 	case <-w.ctx.Done():
+		putBuffer(buf)
 		fmt.Printf("ToHttpWriter.Write(): context canceled, can't write log\n")
 	default:
 		select {
-		case w.logCh <- l:
+		case w.logCh <- buf:
 		default:
+			putBuffer(buf)
 			fmt.Printf("ToHttpWriter.Write(): log channel full, dropping log\n")
 		}
 	}
@@ -126,6 +156,25 @@ func (w *ToHttpWriter) Write(l *Log) error {
 	return nil
 }
 
+// Flush blocks until every entry queued before the call is sent, or ctx is done.
+func (w *ToHttpWriter) Flush(ctx context.Context) error {
+	done := make(chan struct{})
+	select {
+	case w.flushCh <- done:
+	case <-w.stopped:
+		return nil
+	case <-ctx.Done():
+		return joinError("ToHttpWriter.Flush()", ctx.Err())
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return joinError("ToHttpWriter.Flush()", ctx.Err())
+	}
+}
+
 func (w *ToHttpWriter) Close() {
 	w.cancel()
 	w.wg.Wait()
@@ -133,31 +182,38 @@ func (w *ToHttpWriter) Close() {
 
 func (w *ToHttpWriter) run() {
 	defer w.wg.Done()
+	defer close(w.stopped)
+
+	// drain sends everything currently queued
+	drain := func() {
+		for {
+			select {
+			case l := <-w.logCh:
+				w.sendLog(l)
+			default:
+				return
+			}
+		}
+	}
+
 	for {
 		select {
 		case <-w.ctx.Done():
-			for {
-				select {
-				case l := <-w.logCh:
-					w.sendLog(l)
-				default:
-					return
-				}
-			}
+			drain()
+			return
+		case done := <-w.flushCh:
+			drain()
+			close(done)
 		case l := <-w.logCh:
 			w.sendLog(l)
 		}
 	}
 }
 
-func (w *ToHttpWriter) sendLog(l *Log) {
-	b, err := encodeLog(l, w.format)
-	if err != nil {
-		fmt.Printf("ToHttpWriter.sendLog(): failed to encode log: %v\n", err)
-		return
-	}
+func (w *ToHttpWriter) sendLog(b *bytes.Buffer) {
+	defer putBuffer(b)
 
-	req, err := http.NewRequest(w.method, w.url, bytes.NewReader(b))
+	req, err := http.NewRequest(w.method, w.url, bytes.NewReader(b.Bytes()))
 	if err != nil {
 		fmt.Printf("ToHttpWriter.sendLog(): failed to create request: %v\n", err)
 		return
@@ -180,3 +236,10 @@ func (w *ToHttpWriter) sendLog(l *Log) {
 		return
 	}
 }
+
+var (
+	_ Writer      = &ToHttpWriter{}
+	_ LevelSetter = &ToHttpWriter{}
+	_ StatFilter  = &ToHttpWriter{}
+	_ Flusher     = &ToHttpWriter{}
+)
@@ -1,7 +1,6 @@
 package slog
 
 import (
-	"encoding/json"
 	"os"
 	"sync"
 )
@@ -16,12 +15,14 @@ const (
 type toStdStreamWriter struct {
 	mu     sync.RWMutex
 	level  LogLevel
+	stats  StatMode
 	format LogFormat
 	Stream StdStream
 }
 
 type ToStdStreamWriterOptions struct {
 	Level  LogLevel
+	Stats  StatMode
 	Format LogFormat
 	Stream StdStream
 }
@@ -32,6 +33,17 @@ func (w *toStdStreamWriter) Level() LogLevel {
 	return w.level
 }
 
+func (w *toStdStreamWriter) SetLevel(l LogLevel) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	defer levelsChanged()
+	w.level = l
+}
+
+func (w *toStdStreamWriter) StatMode() StatMode {
+	return w.stats
+}
+
 func WithStdIoWriter(opt *ToStdStreamWriterOptions) *toStdStreamWriter {
 	if opt == nil {
 		// will be skipped
@@ -40,6 +52,7 @@ func WithStdIoWriter(opt *ToStdStreamWriterOptions) *toStdStreamWriter {
 
 	return &toStdStreamWriter{
 		level:  opt.Level,
+		stats:  opt.Stats,
 		format: opt.Format,
 		Stream: opt.Stream,
 	}
@@ -50,30 +63,18 @@ func (w *toStdStreamWriter) Write(l *Log) error {
 		return nil
 	}
 
-	if l.Level < w.Level() {
+	if !writerAcceptsLog(w, l) {
 		return nil
 	}
 
-	var b []byte
-	var err error
-
-	switch w.format {
-	case FormatJson:
-		b, err = json.Marshal(l)
-		if err != nil {
-			return err
-		}
-		b = append(b, '\n')
-
-	case FormatText:
-		b = removeAnsiBytes([]byte(l.Str))
-
-	case FormatAnsi:
-		b = []byte(l.Str)
-
-	default:
-		b = []byte(l.Str)
+	format := w.format
+	if format != FormatJson && format != FormatText {
+		format = FormatAnsi
+	}
 
+	b, err := encodeLog(l, format)
+	if err != nil {
+		return err
 	}
 
 	switch w.Stream {
@@ -90,4 +91,8 @@ func (w *toStdStreamWriter) Write(l *Log) error {
 
 func (w *toStdStreamWriter) Close() {}
 
-var _ Writer = &toStdStreamWriter{}
+var (
+	_ Writer      = &toStdStreamWriter{}
+	_ LevelSetter = &toStdStreamWriter{}
+	_ StatFilter  = &toStdStreamWriter{}
+)